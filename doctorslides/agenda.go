@@ -0,0 +1,60 @@
+package doctorslides
+
+import (
+	"google.golang.org/api/slides/v1"
+	"unicode/utf16"
+)
+
+const AGENDA_TITLE = "Agenda"
+
+// buildAgendaRequests lists the content slide titles on the agenda slide,
+// each one linking to its slide. targets are the content slides in order.
+func buildAgendaRequests(slide *slides.Page, outline GPTOutline, targets []*slides.Page) []*slides.Request {
+	agenda := SimpleSlide{Title: AGENDA_TITLE}
+	for _, slideOutline := range outline.Slides {
+		agenda.Bullets = append(agenda.Bullets, Bullet{Text: slideOutline.Title})
+	}
+	requests := buildContentTextRequests(slide, agenda)
+	if len(agenda.Bullets) == 0 {
+		return requests
+	}
+
+	// The links need the position of each title in the body text, which
+	// Slides counts in UTF-16 code units. A custom glyph is part of the text
+	// so it has to be stepped over, unlike the preset bullets.
+	_, bodyId := findTextBox(slide, "body", "BODY", "SUBTITLE")
+	prefix := 0
+	if glyph := customBulletGlyph(); glyph != "" {
+		prefix = utf16Length(glyph + " ")
+	}
+	offset := 0
+	for i, bullet := range agenda.Bullets {
+		length := utf16Length(bullet.Text)
+		if length > 0 && i < len(targets) {
+			start := int64(offset + prefix)
+			end := start + int64(length)
+			requests = append(requests, &slides.Request{
+				UpdateTextStyle: &slides.UpdateTextStyleRequest{
+					ObjectId: bodyId,
+					TextRange: &slides.Range{
+						Type:       "FIXED_RANGE",
+						StartIndex: &start,
+						EndIndex:   &end,
+					},
+					Style: &slides.TextStyle{
+						Link: &slides.Link{PageObjectId: targets[i].ObjectId},
+					},
+					Fields: "link",
+				},
+			})
+		}
+		// Plus one for the newline between lines
+		offset += prefix + length + 1
+	}
+
+	return requests
+}
+
+func utf16Length(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
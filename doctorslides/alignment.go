@@ -0,0 +1,66 @@
+package doctorslides
+
+import (
+	"google.golang.org/api/slides/v1"
+)
+
+var (
+	BODY_ALIGNS  = []string{"left", "center", "right"}
+	BODY_VALIGNS = []string{"top", "middle", "bottom"}
+)
+
+// paragraphAlignment turns --body-align into what Slides calls it. Slides
+// goes by the reading direction rather than the page, so left and right
+// swap over for right to left languages.
+func paragraphAlignment(align string) string {
+	start, end := "START", "END"
+	if isRightToLeft(options.Lang) {
+		start, end = end, start
+	}
+	switch align {
+	case "center":
+		return "CENTER"
+	case "right":
+		return end
+	}
+
+	return start
+}
+
+// buildBodyAlignmentRequests lines up the body text the way --body-align and
+// --body-valign ask. Top left is what Slides does anyway, so that's left
+// alone and the requests come out the same as they always have.
+func buildBodyAlignmentRequests(bodyId string) []*slides.Request {
+	requests := make([]*slides.Request, 0)
+	if options.BodyAlign != "left" {
+		requests = append(requests, &slides.Request{
+			UpdateParagraphStyle: &slides.UpdateParagraphStyleRequest{
+				ObjectId: bodyId,
+				TextRange: &slides.Range{
+					Type: "ALL",
+				},
+				Style: &slides.ParagraphStyle{
+					Alignment: paragraphAlignment(options.BodyAlign),
+				},
+				Fields: "alignment",
+			},
+		})
+	}
+	if options.BodyValign != "top" {
+		anchor := "MIDDLE"
+		if options.BodyValign == "bottom" {
+			anchor = "BOTTOM"
+		}
+		requests = append(requests, &slides.Request{
+			UpdateShapeProperties: &slides.UpdateShapePropertiesRequest{
+				ObjectId: bodyId,
+				ShapeProperties: &slides.ShapeProperties{
+					ContentAlignment: anchor,
+				},
+				Fields: "contentAlignment",
+			},
+		})
+	}
+
+	return requests
+}
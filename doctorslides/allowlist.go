@@ -0,0 +1,43 @@
+package doctorslides
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var errImageNotAllowed = errors.New("the image's domain isn't on --image-allowlist")
+
+// isAllowedImageHost checks an image URL against --image-allowlist. A domain
+// on the list covers its subdomains too, so "unsplash.com" lets
+// "images.unsplash.com" through. Everything is allowed when there's no list.
+func isAllowedImageHost(imageURL string) bool {
+	if len(options.imageAllowlist) == 0 {
+		return true
+	}
+	parsed, err := url.Parse(imageURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	for _, domain := range options.imageAllowlist {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowedImage gives back imageURL if it's allowed and "" if not, noting the
+// ones that get dropped when DEBUG is on
+func allowedImage(imageURL string) string {
+	if imageURL == "" || isAllowedImageHost(imageURL) {
+		return imageURL
+	}
+	if DEBUG {
+		logf("Dropping %s since its domain isn't on --image-allowlist\n", imageURL)
+	}
+
+	return ""
+}
@@ -0,0 +1,95 @@
+package doctorslides
+
+import (
+	"context"
+	"flag"
+	"io"
+	"sync"
+)
+
+// The functions in here are for using Doctor Slides from other Go programs.
+// Everything underneath still runs off the one set of options the command
+// line uses, so calls take turns rather than running side by side, and they
+// use the same credentials.json and token.json as the binary does. Settings
+// like OPEN_AI_KEY come from the environment, or a .env read with LoadEnv.
+
+var libraryMutex sync.Mutex
+
+// DefaultOptions are the options the command line starts from before any
+// flags are given
+func DefaultOptions() Options {
+	libraryMutex.Lock()
+	defer libraryMutex.Unlock()
+	saved := options
+	fs := flag.NewFlagSet("library", flag.ContinueOnError)
+	registerCommonFlags(fs)
+	registerOutlineFlags(fs)
+	registerSlideFlags(fs)
+	defaults := options
+	options = saved
+
+	return defaults
+}
+
+// runLibraryCall runs part of the pipeline with opts in place of the flags.
+// The pipeline gives up with fatalf, which would end the calling program, so
+// that panics here instead and comes back as the error, still wrapping
+// ErrDocNotFound and the like when that's what went wrong.
+func runLibraryCall(ctx context.Context, opts Options, call func()) (err error) {
+	libraryMutex.Lock()
+	defer libraryMutex.Unlock()
+	savedOptions, savedCTX, savedLog := options, CTX, LOG
+	defer func() {
+		options, CTX, LOG = savedOptions, savedCTX, savedLog
+		if r := recover(); r != nil {
+			err = panicError(r)
+		}
+	}()
+	options = opts
+	options.command = "library"
+	options.collectFailures = true
+	CTX = ctx
+	LOG = io.Discard
+	if opts.Log != nil {
+		LOG = opts.Log
+	}
+	validateOutlineOptions()
+	validateSlideOptions()
+	call()
+
+	return nil
+}
+
+// GenerateOutline asks GPT for an outline of the text, cleaned up the same
+// way the command line does it
+func GenerateOutline(ctx context.Context, text string, opts Options) (GPTOutline, error) {
+	var outline GPTOutline
+	err := runLibraryCall(ctx, opts, func() {
+		requireOpenAIKey()
+		outline = buildOutlineFromText(text, "the text")
+	})
+
+	return outline, err
+}
+
+// GenerateOutlineFromDocument is GenerateOutline for a Google Doc
+func GenerateOutlineFromDocument(ctx context.Context, documentId string, opts Options) (GPTOutline, error) {
+	var outline GPTOutline
+	err := runLibraryCall(ctx, opts, func() {
+		requireOpenAIKey()
+		outline = buildOutlineFromDocument(documentId)
+	})
+
+	return outline, err
+}
+
+// BuildPresentation makes a Google Slides deck from the outline and gives
+// back its URL
+func BuildPresentation(ctx context.Context, outline GPTOutline, opts Options) (string, error) {
+	var url string
+	err := runLibraryCall(ctx, opts, func() {
+		_, url = writeToSlides(outline)
+	})
+
+	return url, err
+}
@@ -0,0 +1,60 @@
+package doctorslides
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLibraryCallKeepsErrors(t *testing.T) {
+	useDefaultOptions(t)
+	err := runLibraryCall(context.Background(), DefaultOptions(), func() {
+		err := fmt.Errorf("%w: %s", ErrDocNotFound, "abc123")
+		fatalWithCode(exitCode(err), "%s", err)
+	})
+	if !errors.Is(err, ErrDocNotFound) {
+		t.Fatalf("got %v, want it to wrap ErrDocNotFound", err)
+	}
+	if err.Error() != "there's no document with that ID: abc123" {
+		t.Errorf("message was %q", err.Error())
+	}
+
+	err = runLibraryCall(context.Background(), DefaultOptions(), func() {
+		panic(ErrDocNoAccess)
+	})
+	if !errors.Is(err, ErrDocNoAccess) {
+		t.Errorf("got %v, want ErrDocNoAccess", err)
+	}
+}
+
+func TestLibraryCallLog(t *testing.T) {
+	useDefaultOptions(t)
+	var log bytes.Buffer
+	opts := DefaultOptions()
+	opts.Log = &log
+	runLibraryCall(context.Background(), opts, func() {
+		logln("hello from the pipeline")
+	})
+	if !strings.Contains(log.String(), "hello from the pipeline") {
+		t.Errorf("log was %q", log.String())
+	}
+}
+
+func TestLibraryCallOpenAIKey(t *testing.T) {
+	useDefaultOptions(t)
+	saved := OPEN_AI_KEY
+	OPEN_AI_KEY = ""
+	t.Cleanup(func() { OPEN_AI_KEY = saved })
+
+	opts := DefaultOptions()
+	if err := runLibraryCall(context.Background(), opts, requireOpenAIKey); err == nil {
+		t.Errorf("no key should be an error")
+	}
+	opts.OpenAIKey = "sk-test"
+	if err := runLibraryCall(context.Background(), opts, requireOpenAIKey); err != nil {
+		t.Errorf("got %v with the key in the options", err)
+	}
+}
@@ -0,0 +1,66 @@
+package doctorslides
+
+import (
+	"google.golang.org/api/slides/v1"
+	"math"
+)
+
+var AUTOFITS = []string{"none", "shrink"}
+
+// The body text size the default theme uses, and how small --autofit shrink
+// will go before giving up and letting it run over
+const (
+	BODY_FONT_SIZE        = 18
+	MIN_AUTOFIT_FONT_SIZE = 10
+)
+
+// autofitFontSize works out how small the body text needs to be for the
+// slide to fit, going by the same budget as the overflow check. Text takes
+// up area, so halving the font fits four times as much. Zero means it fits
+// as it is.
+func autofitFontSize(slide SimpleSlide) float64 {
+	chars := 0
+	for _, bullet := range slide.Bullets {
+		chars += len([]rune(bullet.Text))
+	}
+	ratio := math.Max(
+		float64(chars)/float64(options.FitMaxChars),
+		float64(len(slide.Bullets))/float64(options.FitMaxBullets),
+	)
+	if ratio <= 1 {
+		return 0
+	}
+
+	return math.Max(math.Floor(BODY_FONT_SIZE/math.Sqrt(ratio)), MIN_AUTOFIT_FONT_SIZE)
+}
+
+// buildAutofitRequests shrinks the body text on a crowded content slide.
+// Slides only lets the API turn its own shrink on overflow off, not on, so
+// the size gets worked out here and set on the text instead.
+func buildAutofitRequests(bodyId string, slide SimpleSlide) []*slides.Request {
+	if options.Autofit != "shrink" {
+		return nil
+	}
+	size := autofitFontSize(slide)
+	if size == 0 {
+		return nil
+	}
+
+	return []*slides.Request{
+		{
+			UpdateTextStyle: &slides.UpdateTextStyleRequest{
+				ObjectId: bodyId,
+				TextRange: &slides.Range{
+					Type: "ALL",
+				},
+				Style: &slides.TextStyle{
+					FontSize: &slides.Dimension{
+						Magnitude: size,
+						Unit:      "PT",
+					},
+				},
+				Fields: "fontSize",
+			},
+		},
+	}
+}
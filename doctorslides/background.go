@@ -0,0 +1,111 @@
+package doctorslides
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/api/slides/v1"
+	"strconv"
+	"strings"
+)
+
+// backgroundFill works out what goes behind the content slides for
+// --background-image. It gets checked the same way slide images do, and a
+// background that won't load becomes a plain light gray instead of a
+// failed deck.
+func backgroundFill(imageURL string) *slides.PageBackgroundFill {
+	ctx, cancel := context.WithTimeout(CTX, options.ImageTimeout)
+	defer cancel()
+	err := validateImageURL(ctx, imageURL)
+	if err != nil {
+		logf("Could not use the background image, going with a plain one instead: %s\n", err)
+		return &slides.PageBackgroundFill{
+			SolidFill: &slides.SolidFill{
+				Color: &slides.OpaqueColor{
+					RgbColor: &slides.RgbColor{Red: 0.95, Green: 0.95, Blue: 0.95},
+				},
+			},
+		}
+	}
+
+	return &slides.PageBackgroundFill{
+		StretchedPictureFill: &slides.StretchedPictureFill{
+			ContentUrl: imageURL,
+		},
+	}
+}
+
+func buildBackgroundRequest(slideId string, fill *slides.PageBackgroundFill) *slides.Request {
+	return &slides.Request{
+		UpdatePageProperties: &slides.UpdatePagePropertiesRequest{
+			ObjectId: slideId,
+			PageProperties: &slides.PageProperties{
+				PageBackgroundFill: fill,
+			},
+			Fields: "pageBackgroundFill",
+		},
+	}
+}
+
+// NAMED_COLORS are the color names a Background: line can use instead of hex
+var NAMED_COLORS = map[string]uint32{
+	"black":     0x000000,
+	"white":     0xffffff,
+	"gray":      0x808080,
+	"grey":      0x808080,
+	"lightgray": 0xd3d3d3,
+	"lightgrey": 0xd3d3d3,
+	"darkgray":  0x404040,
+	"darkgrey":  0x404040,
+	"red":       0xdb4437,
+	"orange":    0xff9800,
+	"yellow":    0xfdd835,
+	"green":     0x0f9d58,
+	"teal":      0x009688,
+	"blue":      0x4285f4,
+	"navy":      0x1a237e,
+	"purple":    0x7b1fa2,
+	"pink":      0xe91e63,
+	"brown":     0x795548,
+	"beige":     0xf5f5dc,
+	"cream":     0xfffdd0,
+}
+
+// parseColor reads a color like #1a73e8, #fff, or one of NAMED_COLORS
+func parseColor(value string) (*slides.RgbColor, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if named, ok := NAMED_COLORS[strings.ReplaceAll(value, " ", "")]; ok {
+		return rgb(named), nil
+	}
+	if !strings.HasPrefix(value, "#") {
+		return nil, fmt.Errorf("\"%s\" isn't a hex color like #1a73e8 or a color name I know", value)
+	}
+	hex := strings.TrimPrefix(value, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	number, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return nil, fmt.Errorf("\"%s\" isn't a hex color like #1a73e8", value)
+	}
+
+	return rgb(uint32(number)), nil
+}
+
+// slideBackgroundFill is the solid fill for a slide's own Background, or nil
+// when it doesn't have one that works
+func slideBackgroundFill(slide SimpleSlide) *slides.PageBackgroundFill {
+	if slide.Background == "" {
+		return nil
+	}
+	color, err := parseColor(slide.Background)
+	if err != nil {
+		logf("WARNING: Leaving the background off \"%s\": %s\n", slide.Title, err)
+		return nil
+	}
+
+	return &slides.PageBackgroundFill{
+		SolidFill: &slides.SolidFill{
+			Color: &slides.OpaqueColor{RgbColor: color},
+		},
+	}
+}
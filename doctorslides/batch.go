@@ -0,0 +1,111 @@
+package doctorslides
+
+import (
+	"encoding/json"
+	"errors"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/slides/v1"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Slides names the request it didn't like in the error, like
+// "Invalid requests[12].createImage: ..."
+var BAD_REQUEST_INDEX = regexp.MustCompile(`requests\[(\d+)\]`)
+
+// MAX_SKIPPED_REQUESTS is how many bad requests --skip-bad-requests will drop
+// before deciding something's wrong with the whole batch
+const MAX_SKIPPED_REQUESTS = 10
+
+// badRequestIndex works out which request in the batch Slides rejected, when
+// the error says
+func badRequestIndex(err error, requestCount int) (int, bool) {
+	message := err.Error()
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		message = apiErr.Message + " " + apiErr.Body
+	}
+	match := BAD_REQUEST_INDEX.FindStringSubmatch(message)
+	if match == nil {
+		return 0, false
+	}
+	index, err := strconv.Atoi(match[1])
+	if err != nil || index >= requestCount {
+		return 0, false
+	}
+
+	return index, true
+}
+
+// createdObjectId is the ID of whatever a request makes, if it makes anything
+// we give an ID to
+func createdObjectId(request *slides.Request) string {
+	switch {
+	case request.CreateShape != nil:
+		return request.CreateShape.ObjectId
+	case request.CreateImage != nil:
+		return request.CreateImage.ObjectId
+	case request.CreateTable != nil:
+		return request.CreateTable.ObjectId
+	}
+
+	return ""
+}
+
+// mentionsObject is true for requests that point at the object. Checking the
+// JSON saves going through every kind of request there is one by one.
+func mentionsObject(request *slides.Request, objectId string) bool {
+	encoded, err := json.Marshal(request)
+	if err != nil {
+		return false
+	}
+
+	return strings.Contains(string(encoded), "\""+objectId+"\"")
+}
+
+// dropRequest takes the bad request out of the batch, along with everything
+// that was going to use what it made, since those would only fail next
+func dropRequest(requests []*slides.Request, index int) ([]*slides.Request, int) {
+	objectId := createdObjectId(requests[index])
+	kept := make([]*slides.Request, 0, len(requests))
+	dropped := 0
+	for i, request := range requests {
+		if i == index || (objectId != "" && mentionsObject(request, objectId)) {
+			dropped++
+			continue
+		}
+		kept = append(kept, request)
+	}
+
+	return kept, dropped
+}
+
+// batchUpdate sends the batch as is, unless --skip-bad-requests is on. Then,
+// when Slides turns it down over one request, that request comes out and the
+// rest get sent again, so a single bad image doesn't leave the deck empty.
+func batchUpdate(writer SlideWriter, presentationId string, updates *slides.BatchUpdatePresentationRequest) error {
+	_, err := writer.BatchUpdate(presentationId, updates)
+	if err == nil || !options.SkipBadRequests {
+		return err
+	}
+	requests := updates.Requests
+	for skipped := 0; skipped < MAX_SKIPPED_REQUESTS; skipped++ {
+		index, found := badRequestIndex(err, len(requests))
+		if !found {
+			return err
+		}
+		logf("WARNING: Slides rejected a request, skipping it and trying the rest again: %s\n", err)
+		var dropped int
+		requests, dropped = dropRequest(requests, index)
+		if dropped > 1 {
+			logf("WARNING: Also skipped %d requests that depended on it\n", dropped-1)
+		}
+		_, err = writer.BatchUpdate(presentationId, &slides.BatchUpdatePresentationRequest{Requests: requests})
+		if err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
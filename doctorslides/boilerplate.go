@@ -0,0 +1,89 @@
+package doctorslides
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// BOILERPLATE_PATTERNS are the lines that show up in template heavy docs and
+// never belong on a slide. Each one has to match the whole line.
+var BOILERPLATE_PATTERNS = []string{
+	// Page numbers on their own, like "4", "Page 4", or "4 of 12"
+	`(?i)^(page\s*)?\d+(\s*(of|/)\s*\d+)?$`,
+	// Table of contents headings and entries with dot leaders
+	`(?i)^(table of )?contents$`,
+	`^.+\.{3,}\s*\d+$`,
+	// Confidentiality notices
+	`(?i)^(strictly )?(confidential|internal use only|do not distribute|proprietary)\b.*$`,
+	`(?i)^©.*all rights reserved\.?$`,
+}
+
+// boilerplatePatterns compiles the built in patterns plus any from
+// --strip-patterns, which is a file with one regular expression per line.
+// Blank lines and lines starting with # in that file are skipped.
+func boilerplatePatterns(path string) []*regexp.Regexp {
+	sources := append([]string{}, BOILERPLATE_PATTERNS...)
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			fatalf("Could not read the strip patterns: %s", err)
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			sources = append(sources, line)
+		}
+		if err := scanner.Err(); err != nil {
+			fatalf("Could not read the strip patterns: %s", err)
+		}
+	}
+
+	patterns := make([]*regexp.Regexp, 0)
+	for _, source := range sources {
+		pattern, err := regexp.Compile(source)
+		if err != nil {
+			fatalf("\"%s\" isn't a valid strip pattern: %s", source, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns
+}
+
+// stripBoilerplate drops every line of the text that matches one of the
+// patterns, so GPT doesn't spend tokens on (or make slides about) them
+func stripBoilerplate(text string, patterns []*regexp.Regexp) string {
+	kept := make([]string, 0)
+	stripped := 0
+	for _, line := range strings.Split(text, "\n") {
+		if isBoilerplate(strings.TrimSpace(line), patterns) {
+			stripped++
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if stripped > 0 && DEBUG {
+		logf("Stripped %d lines of boilerplate\n", stripped)
+	}
+
+	return strings.Join(kept, "\n")
+}
+
+func isBoilerplate(line string, patterns []*regexp.Regexp) bool {
+	if line == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if pattern.MatchString(line) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,84 @@
+package doctorslides
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// BULLET_PRESETS maps the --bullet-glyph names to the Slides API presets
+var BULLET_PRESETS = map[string]string{
+	"DISC":     "BULLET_DISC_CIRCLE_SQUARE",
+	"ARROW":    "BULLET_ARROW_DIAMOND_DISC",
+	"CHECKBOX": "BULLET_CHECKBOX",
+	"DIAMOND":  "BULLET_DIAMONDX_ARROW3D_SQUARE",
+	"STAR":     "BULLET_STAR_CIRCLE_SQUARE",
+}
+
+// NUMBERED_PRESETS maps the --number-style names to the Slides API presets.
+// Each one steps through other styles for the nested levels.
+var NUMBERED_PRESETS = map[string]string{
+	"DIGIT":        "NUMBERED_DIGIT_ALPHA_ROMAN",
+	"DIGIT_PARENS": "NUMBERED_DIGIT_ALPHA_ROMAN_PARENS",
+	"DIGIT_NESTED": "NUMBERED_DIGIT_NESTED",
+	"ZERO_DIGIT":   "NUMBERED_ZERODIGIT_ALPHA_ROMAN",
+	"ALPHA":        "NUMBERED_UPPERALPHA_ALPHA_ROMAN",
+	"ROMAN":        "NUMBERED_UPPERROMAN_UPPERALPHA_DIGIT",
+}
+
+func numberStyleChoices() string {
+	return "DIGIT, DIGIT_PARENS, DIGIT_NESTED, ZERO_DIGIT, ALPHA, or ROMAN"
+}
+
+// isValidBulletGlyph accepts the preset names, NONE, or any single character
+// to use as a hand-made glyph
+func isValidBulletGlyph(glyph string) bool {
+	if _, ok := BULLET_PRESETS[glyph]; ok {
+		return true
+	}
+
+	return glyph == "NONE" || utf8.RuneCountInString(glyph) == 1
+}
+
+// customBulletGlyph is the character to type in front of each bullet, for when
+// the glyph isn't one Slides has a preset for. It's empty otherwise.
+func customBulletGlyph() string {
+	if options.NoBullets || options.NumberedBullets || options.BulletGlyph == "NONE" {
+		return ""
+	}
+	if _, ok := BULLET_PRESETS[options.BulletGlyph]; ok {
+		return ""
+	}
+
+	return options.BulletGlyph
+}
+
+// bulletPreset is the preset to apply over the slide body. The bool is false
+// when the body shouldn't get real bullets at all.
+func bulletPreset() (string, bool) {
+	if options.NoBullets {
+		return "", false
+	}
+	if options.NumberedBullets {
+		return NUMBERED_PRESETS[options.NumberStyle], true
+	}
+	preset, ok := BULLET_PRESETS[options.BulletGlyph]
+
+	return preset, ok
+}
+
+func bulletGlyphChoices() string {
+	return "DISC, ARROW, CHECKBOX, DIAMOND, STAR, NONE, or a single character"
+}
+
+// prefixBulletGlyph puts a hand-made glyph in front of each line, after any
+// tabs so the nesting still lines up
+func prefixBulletGlyph(body string, glyph string) string {
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		text := strings.TrimLeft(line, "\t")
+		tabs := line[:len(line)-len(text)]
+		lines[i] = tabs + glyph + " " + text
+	}
+
+	return strings.Join(lines, "\n")
+}
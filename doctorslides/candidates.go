@@ -0,0 +1,78 @@
+package doctorslides
+
+import (
+	"net/url"
+)
+
+// scoreOutline rates how well a parsed outline turned out, higher being
+// better. Landing in the slide range we asked for counts the most, then
+// having real titles, then having image URLs that at least look like URLs.
+func scoreOutline(outline GPTOutline) int {
+	low, high := options.MinSlides, options.MaxSlides
+	if options.ExactSlides > 0 {
+		low, high = options.ExactSlides, options.ExactSlides
+	}
+	count := len(outline.Slides)
+	score := 0
+	switch {
+	case count < low:
+		score -= low - count
+	case count > high:
+		score -= count - high
+	default:
+		score += 10
+	}
+	for _, slide := range outline.Slides {
+		if slide.Title == UNNAMED_TITLE {
+			score -= 3
+		}
+		if looksLikeImageURL(slide.Image) {
+			score++
+		}
+	}
+
+	return score
+}
+
+func looksLikeImageURL(imageURL string) bool {
+	parsed, err := url.ParseRequestURI(imageURL)
+	if err != nil {
+		return false
+	}
+
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// pickOutline parses every candidate GPT sent back and keeps the best scoring
+// one, handing back the text it came from too. Ties go to whichever came
+// first, and candidates that don't parse at all are skipped. The bool is
+// false when none of them parsed.
+func pickOutline(candidates []string) (GPTOutline, string, bool) {
+	best := -1
+	var bestOutline GPTOutline
+	var bestScore int
+	for i, candidate := range candidates {
+		outline := parseGPTOutline(candidate)
+		if len(outline.Slides) == 0 {
+			if DEBUG && len(candidates) > 1 {
+				logf("Candidate %d: garbage\n", i+1)
+			}
+			continue
+		}
+		score := scoreOutline(outline)
+		if DEBUG && len(candidates) > 1 {
+			logf("Candidate %d: %d slides, score %d\n", i+1, len(outline.Slides), score)
+		}
+		if best == -1 || score > bestScore {
+			best, bestOutline, bestScore = i, outline, score
+		}
+	}
+	if best == -1 {
+		return GPTOutline{}, "", false
+	}
+	if DEBUG && len(candidates) > 1 {
+		logf("Going with candidate %d\n", best+1)
+	}
+
+	return bestOutline, candidates[best], true
+}
@@ -0,0 +1,70 @@
+package doctorslides
+
+import (
+	"strings"
+)
+
+// CHECKBOX_PRESET is the bullet that slides made of checklist items get
+const CHECKBOX_PRESET = "BULLET_CHECKBOX"
+
+// CHECKBOX_MARKERS start a checklist item, and say whether it's checked off
+var CHECKBOX_MARKERS = map[string]bool{
+	"[ ] ": false,
+	"[x] ": true,
+	"[X] ": true,
+}
+
+// parseCheckbox takes a [ ] or [x] off the front of a bullet. The first bool
+// is whether it was a checklist item at all, the second whether it's checked.
+func parseCheckbox(text string) (string, bool, bool) {
+	for marker, checked := range CHECKBOX_MARKERS {
+		if strings.HasPrefix(text, marker) {
+			return strings.TrimSpace(strings.TrimPrefix(text, marker)), true, checked
+		}
+	}
+
+	return text, false, false
+}
+
+func checklistBullet(text string, level int) Bullet {
+	text, checkbox, checked := parseCheckbox(text)
+
+	return Bullet{
+		Text:     text,
+		Level:    level,
+		Checkbox: checkbox,
+		Checked:  checked,
+	}
+}
+
+// checkboxMarker puts the [ ] or [x] back for writing the bullet out as text
+func checkboxMarker(bullet Bullet) string {
+	if !bullet.Checkbox {
+		return ""
+	}
+	if bullet.Checked {
+		return "[x] "
+	}
+
+	return "[ ] "
+}
+
+// isChecklist is true for a slide with any checklist items on it. The
+// checkbox preset goes over the whole body, so one is enough.
+func isChecklist(bullets []Bullet) bool {
+	for _, bullet := range bullets {
+		if bullet.Checkbox {
+			return true
+		}
+	}
+
+	return false
+}
+
+func checklistInstruction() string {
+	if !options.Checklist {
+		return ""
+	}
+
+	return " When the document lists tasks or requirements, write each of those bullet points starting with \"[ ] \", or with \"[x] \" if the document says it's done."
+}
@@ -0,0 +1,33 @@
+package doctorslides
+
+import (
+	"testing"
+)
+
+func TestChecklistBullets(t *testing.T) {
+	tests := []struct {
+		line     string
+		text     string
+		checkbox bool
+		checked  bool
+	}{
+		{"- [ ] Book the venue", "Book the venue", true, false},
+		{"- [x] Send the invites", "Send the invites", true, true},
+		{"- [X] Order the food", "Order the food", true, true},
+		{"- Bring a jacket", "Bring a jacket", false, false},
+		{"- [x]Not a checkbox", "[x]Not a checkbox", false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.line, func(t *testing.T) {
+			useDefaultOptions(t)
+			outline := parseGPTOutline("NEW SLIDE ======\nTitle: Party\n" + test.line + "\nEND SLIDE ======\n")
+			if len(outline.Slides) != 1 || len(outline.Slides[0].Bullets) != 1 {
+				t.Fatalf("want one slide with one bullet, got %+v", outline.Slides)
+			}
+			bullet := outline.Slides[0].Bullets[0]
+			if bullet.Text != test.text || bullet.Checkbox != test.checkbox || bullet.Checked != test.checked {
+				t.Errorf("got %q checkbox=%v checked=%v, want %q checkbox=%v checked=%v", bullet.Text, bullet.Checkbox, bullet.Checked, test.text, test.checkbox, test.checked)
+			}
+		})
+	}
+}
@@ -0,0 +1,797 @@
+package doctorslides
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const VERSION = "0.1.0"
+
+// Options holds everything that can be tweaked from the command line. The
+// subcommand flag sets write straight into it before the pipeline runs.
+type Options struct {
+	// command is the subcommand being run, for anything that behaves
+	// differently between them
+	command string
+	// explicit has the names of the flags that were actually given on the
+	// command line, as opposed to left at their defaults
+	explicit map[string]bool
+
+	// OpenAIKey and Log are only for calling the package from Go. The key
+	// is used instead of OPEN_AI_KEY when it's set, and Log gets the progress
+	// messages, which are thrown away when it's nil.
+	OpenAIKey string
+	Log       io.Writer
+
+	Preset      string
+	TraceHTTP   bool
+	QuietGoogle bool
+
+	ExportFormat string
+	ExportOutput string
+
+	SelfTest     bool
+	SelfTestLive bool
+	PrintURLOnly bool
+	JSONResult   bool
+	DryRun       bool
+
+	WriteOutline     string
+	FromOutline      string
+	CheckImages      bool
+	FailOnDeadImages bool
+
+	FromFolder        string
+	Concurrency       int
+	StateFile         string
+	Force             bool
+	SharedDrive       string
+	OutputFolder      string
+	ReplaceExisting   bool
+	ImportPptx        string
+	KeepPptx          bool
+	Target            string
+	OneDriveFolder    string
+	Yes               bool
+	SkipBadRequests   bool
+	ContentLayout     string
+	BodyAlign         string
+	BodyValign        string
+	Theme             string
+	ThemeFrom         string
+	ClosingLayout     string
+	TitleLayout       string
+	Agenda            bool
+	BackgroundImage   string
+	BackgroundOnTitle bool
+	QRSource          bool
+	EmbedOutline      bool
+	QRURL             string
+	// collectFailures is set while a batch is running so fatalf only fails
+	// the one item instead of quitting altogether
+	collectFailures bool
+
+	PresentationId  string
+	SlideRange      string
+	ExistingNotes   string
+	SpeakerDoc      bool
+	Watch           bool
+	WatchInterval   time.Duration
+	Thumbnails      string
+	ThumbnailSize   string
+	slideRangeStart int
+	slideRangeEnd   int
+
+	ReadMode         string
+	ExportMimeType   string
+	InputHeadings    string
+	inputHeadings    []string
+	imageSources     []string
+	pageWidth        float64
+	pageHeight       float64
+	NoStrip          bool
+	Compact          bool
+	StripPatterns    string
+	Lang             string
+	NotesLang        string
+	GarbageRetries   int
+	Refine           bool
+	Candidates       int
+	FormatConfig     string
+	PromptFile       string
+	NoAI             bool
+	SplitOnHeadings  bool
+	IncludeFootnotes bool
+	Checklist        bool
+	ColorSections    bool
+	FromClipboard    bool
+	Title            string
+	readsText        bool
+	readsMarkdown    bool
+	MaxTokens        int
+	MaxTokensBudget  int
+	MaxCost          float64
+	DumpGPT          string
+	MinSlides        int
+	MaxSlides        int
+	ExactSlides      int
+	MergeShortSlides bool
+	MergeThreshold   int
+	DedupeBullets    bool
+	DedupeThreshold  float64
+	UntitledLabel    string
+	TimeFormat       string
+	AITitles         bool
+
+	NotesFromBullets  bool
+	ExpandNotes       bool
+	NotesWords        int
+	TruncateBullets   bool
+	MaxBulletChars    int
+	MaxBullets        int
+	ContinuationStyle string
+	TruncatedToNotes  bool
+	ConciseBullets    bool
+	EmojiBullets      bool
+	EmojiMap          string
+
+	AutoAdvance        bool
+	WordsPerMinute     int
+	FitMaxChars        int
+	Size               string
+	PageWidth          int64
+	PageHeight         int64
+	FitMaxBullets      int
+	StrictFit          bool
+	ValidateOutline    bool
+	Strict             bool
+	Autofit            string
+	Transition         string
+	ImageSource        string
+	ImageCredit        bool
+	ImageFit           string
+	ImageAllowlist     string
+	imageAllowlist     []string
+	ImageConcurrency   int
+	ImageTimeout       time.Duration
+	NoTitleSlide       bool
+	Subtitle           string
+	Footer             string
+	Author             string
+	FooterDate         bool
+	FooterPosition     string
+	PageNumbers        bool
+	NumberClosingSlide bool
+	NoBullets          bool
+	BulletGlyph        string
+	NumberedBullets    bool
+	NumberStyle        string
+}
+
+var options Options
+
+const usageText = `Usage:
+  doctor_slides generate [flags] <DOCUMENT ID>
+  doctor_slides generate --self-test
+  doctor_slides generate --write-outline outline.json <DOCUMENT ID>
+  doctor_slides generate --from-outline outline.json
+  doctor_slides generate --from-folder <FOLDER ID>
+  doctor_slides generate - < notes.txt
+  doctor_slides generate --from-clipboard
+  doctor_slides export [flags] <DOCUMENT ID>
+  doctor_slides list-layouts [--presentation-id <PRESENTATION ID>]
+  doctor_slides auth login
+  doctor_slides doctor
+  doctor_slides version
+
+Running "doctor_slides <DOCUMENT ID>" is the same as "generate".
+`
+
+func printUsage() {
+	fmt.Print(usageText)
+	fmt.Print("\n" + presetHelp())
+}
+
+func runCLI(args []string) {
+	if len(args) < 1 {
+		logln("I need a document ID to get started, fool.")
+		printUsage()
+		return
+	}
+
+	switch args[0] {
+	case "generate":
+		runGenerate(args[1:])
+	case "export":
+		runExport(args[1:])
+	case "list-layouts":
+		runListLayouts(args[1:])
+	case "auth":
+		runAuth(args[1:])
+	case "doctor":
+		runDoctor(args[1:])
+	case "version":
+		fmt.Printf("doctor_slides %s\n", VERSION)
+	case "help", "-h", "-help", "--help":
+		printUsage()
+	default:
+		// Before there were subcommands the only thing you could do was hand
+		// over a document ID, so anything we don't recognize gets treated as
+		// a generate.
+		runGenerate(args)
+	}
+}
+
+// registerCommonFlags adds the flags that aren't about the deck itself
+func registerCommonFlags(fs *flag.FlagSet) {
+	fs.StringVar(&options.Preset, "preset", "", "start from a bundle of settings: "+presetNames())
+	fs.BoolVar(&options.TraceHTTP, "trace-http", false, "log the method, URL, status, and time of every HTTP request")
+	fs.BoolVar(&options.QuietGoogle, "quiet-google", false, "hide log output from the Google client libraries unless DEBUG is on")
+}
+
+// applyCommonFlags acts on the common flags once everything is parsed
+func applyCommonFlags(fs *flag.FlagSet) {
+	applyPreset(fs, options.Preset)
+	routeLibraryLogs(options.QuietGoogle)
+	if options.TraceHTTP {
+		TRANSPORT = newTracingTransport(TRANSPORT)
+	}
+}
+
+// registerOutlineFlags adds the flags for asking GPT for the outline and the
+// clean up passes that run over it afterwards.
+func registerOutlineFlags(fs *flag.FlagSet) {
+	fs.StringVar(&options.ReadMode, "read-mode", "structured", "how to read the doc: structured (walk the paragraphs) or export (have Drive export it)")
+	fs.StringVar(&options.ExportMimeType, "export-mime", "text/plain", "what --read-mode export asks Drive for: text/plain or text/markdown")
+	fs.StringVar(&options.InputHeadings, "input-headings", "", "only use the parts of the doc under these comma separated headings, like \"Background,Results\"")
+	fs.BoolVar(&options.NoStrip, "no-strip", false, "send the doc to GPT as is, without taking out page numbers, tables of contents, and confidentiality notices")
+	fs.BoolVar(&options.Compact, "compact", false, "squeeze blank lines and trailing spaces out of the doc to save tokens")
+	fs.StringVar(&options.StripPatterns, "strip-patterns", "", "file of extra regular expressions, one per line, for lines to take out of the doc")
+	fs.StringVar(&options.Lang, "lang", "", "language code for the slides, like es or ar, instead of the one detected from the doc (right to left ones get laid out that way)")
+	fs.StringVar(&options.NotesLang, "notes-lang", "", "language code for the speaker notes, if they should be in a different one than the slides (defaults to --lang)")
+	fs.IntVar(&options.MinSlides, "min-slides", 3, "fewest content slides to ask GPT for")
+	fs.IntVar(&options.MaxSlides, "max-slides", 25, "most content slides to ask GPT for")
+	fs.IntVar(&options.ExactSlides, "exact-slides", 0, "ask for exactly this many content slides, padding or trimming to make sure")
+	fs.BoolVar(&options.MergeShortSlides, "merge-short-slides", false, "combine back to back slides that each have fewer than --merge-threshold bullets")
+	fs.IntVar(&options.MergeThreshold, "merge-threshold", 2, "slides with fewer bullets than this count as short for --merge-short-slides")
+	fs.BoolVar(&options.DedupeBullets, "dedupe-bullets", false, "drop bullets that repeat an earlier bullet on the same slide in other words")
+	fs.Float64Var(&options.DedupeThreshold, "dedupe-threshold", 0.6, "how alike two bullets have to be, from 0 to 1, for --dedupe-bullets to count them as the same")
+	fs.BoolVar(&options.Refine, "refine", false, "have GPT critique and improve its outline in a second pass (about doubles the tokens)")
+	fs.IntVar(&options.Candidates, "candidates", 1, "have GPT write this many outlines and keep the best one (costs tokens for each)")
+	fs.BoolVar(&options.FromClipboard, "from-clipboard", false, "make the deck from the text on the clipboard instead of a doc (or pass - as the document to read stdin)")
+	fs.StringVar(&options.Title, "title", "", "title for the deck instead of the doc's, or the first line of text from stdin or the clipboard")
+	fs.BoolVar(&options.SplitOnHeadings, "split-on-headings", false, "have GPT start a new slide at every Heading 1 in the doc")
+	fs.BoolVar(&options.ColorSections, "color-sections", false, "give each section of the doc its own accent color on the slide titles and a bar down the side (goes with --split-on-headings)")
+	fs.BoolVar(&options.Checklist, "checklist", false, "have GPT write tasks and requirements as [ ] and [x] items, which go on the slide as checkboxes")
+	fs.BoolVar(&options.IncludeFootnotes, "include-footnotes", false, "give GPT the doc's footnotes for the speaker notes, and list them on a References slide at the end")
+	fs.BoolVar(&options.NoAI, "no-ai", false, "make a slide from each top level heading with the text under it as bullets, without GPT")
+	fs.StringVar(&options.PromptFile, "prompt-file", "", "text/template file to use as the whole outline prompt, with {{.Content}} for the document and {{.MinSlides}}, {{.MaxSlides}}, {{.SlideStart}} and the other markers")
+	fs.StringVar(&options.FormatConfig, "format-config", "", "JSON file of the markers GPT writes the outline with, like {\"slideStart\": \"--- SLIDE ---\"}")
+	fs.IntVar(&options.MaxTokens, "max-tokens", 0, "most tokens GPT can use for the outline, 0 for the model's limit")
+	fs.IntVar(&options.MaxTokensBudget, "max-tokens-budget", 0, "stop once the whole run has used this many OpenAI tokens, 0 for no limit")
+	fs.Float64Var(&options.MaxCost, "max-cost", 0, "stop once the whole run has spent about this many US dollars on OpenAI, 0 for no limit")
+	fs.StringVar(&options.DumpGPT, "dump-gpt", "", "save the exact prompt and GPT's raw reply to this file")
+	fs.IntVar(&options.GarbageRetries, "garbage-retries", 2, "how many more times to ask GPT when its outline can't be parsed")
+	fs.StringVar(&options.TimeFormat, "time-format", DEFAULT_TIME_FORMAT, "Go time layout for the local time in the title of a deck that doesn't have one")
+	fs.StringVar(&options.UntitledLabel, "untitled-label", "Untitled", "title for slides GPT didn't title and that have no bullets to make one from")
+	fs.BoolVar(&options.AITitles, "ai-titles", false, "have GPT title the slides it left untitled (costs a call per slide)")
+	fs.BoolVar(&options.NotesFromBullets, "notes-from-bullets", false, "fill in speaker notes from the bullets for slides that don't have any")
+	fs.IntVar(&options.NotesWords, "notes-words", 0, "about how many words of speaker notes each slide should get, with anything much longer cut back at a sentence")
+	fs.BoolVar(&options.ExpandNotes, "expand-notes", false, "with --notes-from-bullets, have GPT turn each bullet into a sentence (costs a call per slide)")
+	fs.IntVar(&options.MaxBullets, "max-bullets", 0, "most bullets to keep on a slide, 0 for no limit")
+	fs.StringVar(&options.ContinuationStyle, "continuation-style", "", "carry the bullets past --max-bullets onto more slides, up to --max-slides, instead of dropping them, titled: "+strings.Join(CONTINUATION_STYLES, ", "))
+	fs.BoolVar(&options.TruncateBullets, "truncate-bullets", false, "shorten long bullets to --max-bullet-chars")
+	fs.IntVar(&options.MaxBulletChars, "max-bullet-chars", 120, "longest a bullet can be when --truncate-bullets is on")
+	fs.BoolVar(&options.EmojiBullets, "emoji-bullets", false, "start bullets with an emoji that fits what they're about, like 💰 for costs")
+	fs.StringVar(&options.EmojiMap, "emoji-map", "", "JSON file of keyword to emoji to use for --emoji-bullets instead of the built in one")
+	fs.BoolVar(&options.TruncatedToNotes, "truncated-to-notes", false, "keep the full text of shortened or dropped bullets in the speaker notes")
+	fs.BoolVar(&options.ConciseBullets, "concise-bullets", false, "cut every bullet down to a short phrase, with the full sentence kept in the notes when there are any")
+}
+
+// registerSlideFlags adds the flags that change how the deck gets built. Both
+// generate and export (for pptx) end up in writeToSlides so they share these.
+func registerSlideFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&options.AutoAdvance, "auto-advance", false, "work out how long each slide should stay up based on how much there is to read")
+	fs.IntVar(&options.WordsPerMinute, "words-per-minute", 130, "reading speed used by --auto-advance")
+	fs.StringVar(&options.Transition, "transition", "NONE", "slide transition: NONE, DISSOLVE, FADE, SLIDE_FROM_RIGHT, SLIDE_FROM_LEFT, FLIP, CUBE, or GALLERY")
+	fs.StringVar(&options.Size, "size", "16:9", "page size for a new deck: 16:9, 4:3, or custom with --page-width and --page-height")
+	fs.Int64Var(&options.PageWidth, "page-width", 0, "page width in EMU for --size custom (914400 to an inch)")
+	fs.Int64Var(&options.PageHeight, "page-height", 0, "page height in EMU for --size custom")
+	fs.IntVar(&options.FitMaxChars, "fit-max-chars", 600, "warn about slides with more body text than this many characters")
+	fs.IntVar(&options.FitMaxBullets, "fit-max-bullets", 7, "warn about slides with more bullets than this")
+	fs.BoolVar(&options.StrictFit, "strict-fit", false, "stop instead of just warning when a slide probably has too much text")
+	fs.BoolVar(&options.ValidateOutline, "validate-outline", false, "check the outline for missing titles, empty or overfull slides, bad image URLs, and the slide count before making the deck")
+	fs.BoolVar(&options.Strict, "strict", false, "like --validate-outline, but stop instead of just warning about what it finds")
+	fs.StringVar(&options.Autofit, "autofit", "none", "shrink the body text on crowded content slides so it fits, instead of letting it run over: "+strings.Join(AUTOFITS, ", "))
+	fs.BoolVar(&options.NoBullets, "no-bullets", false, "put the slide text in as plain paragraphs instead of a bulleted list")
+	fs.StringVar(&options.BulletGlyph, "bullet-glyph", "DISC", "bullet style: "+bulletGlyphChoices())
+	fs.BoolVar(&options.NumberedBullets, "numbered-bullets", false, "number the bullets instead, for content that goes in order (wins over --bullet-glyph)")
+	fs.StringVar(&options.NumberStyle, "number-style", "DIGIT", "how --numbered-bullets counts: "+numberStyleChoices())
+	fs.StringVar(&options.ImageSource, "image-source", "none", "where slide images come from: unsplash, pexels, gpt, dalle, doc, or none, or a list like doc,unsplash,dalle to try in order")
+	fs.BoolVar(&options.ImageCredit, "image-credit", false, "add the photographer credit under images from unsplash or pexels")
+	fs.StringVar(&options.ImageFit, "image-fit", "contain", "how images fill their spot: contain, cover, or stretch")
+	fs.StringVar(&options.ImageAllowlist, "image-allowlist", "", "comma separated domains images are allowed to come from, like \"unsplash.com,images.pexels.com\"")
+	fs.IntVar(&options.ImageConcurrency, "image-concurrency", 4, "how many slide images to look up at the same time")
+	fs.DurationVar(&options.ImageTimeout, "image-timeout", 10*time.Second, "how long to wait on each image lookup")
+	fs.StringVar(&options.SharedDrive, "shared-drive", "", "ID of the shared drive to work in, for folder listing and where the deck goes")
+	fs.StringVar(&options.OutputFolder, "output-folder", "", "ID of the Drive folder to put the new deck in")
+	fs.StringVar(&options.Theme, "theme", "", "colors and fonts for the deck: "+themeNames())
+	fs.StringVar(&options.ThemeFrom, "theme-from", "", "presentation ID to copy the color scheme and fonts from, falling back on --theme")
+	fs.StringVar(&options.BodyAlign, "body-align", "left", "how to line up the body text on content slides: "+strings.Join(BODY_ALIGNS, ", "))
+	fs.StringVar(&options.BodyValign, "body-valign", "top", "where the body text sits in its box on content slides: "+strings.Join(BODY_VALIGNS, ", "))
+	fs.StringVar(&options.ContentLayout, "content-layout", "TITLE_AND_BODY", "predefined layout for the content slides, like TITLE_ONLY or ONE_COLUMN_TEXT")
+	fs.StringVar(&options.ClosingLayout, "closing-layout", "TITLE", "predefined layout for the closing slide")
+	fs.StringVar(&options.TitleLayout, "title-layout", "TITLE", "predefined layout for the title slide, which replaces the one every new deck starts with when it isn't TITLE")
+	fs.StringVar(&options.BackgroundImage, "background-image", "", "URL of an image to stretch across the background of every content slide")
+	fs.BoolVar(&options.BackgroundOnTitle, "background-on-title", false, "put the --background-image on the title slide too")
+	fs.BoolVar(&options.EmbedOutline, "embed-outline", false, "save the outline as JSON in the closing slide's speaker notes, ready for --from-outline")
+	fs.BoolVar(&options.QRSource, "qr-source", false, "put a QR code linking to the source doc on the closing slide (the picture is on Drive only while the deck is built)")
+	fs.StringVar(&options.QRURL, "qr-url", "", "link the QR code to this URL instead of the source doc (turns on --qr-source)")
+	fs.BoolVar(&options.Agenda, "agenda", false, "add an agenda slide after the title listing every content slide, with links to each")
+	fs.BoolVar(&options.SkipBadRequests, "skip-bad-requests", false, "when Slides rejects one request in a batch, leave it out and send the rest again instead of giving up")
+	fs.BoolVar(&options.ReplaceExisting, "replace-existing", false, "move an existing presentation with the same title (in --output-folder, if given) to the trash first")
+	fs.BoolVar(&options.Yes, "yes", false, "don't ask before --replace-existing trashes anything")
+	fs.BoolVar(&options.NoTitleSlide, "no-title-slide", false, "drop the title slide and start the deck on the first content slide")
+	fs.StringVar(&options.Subtitle, "subtitle", "", "subtitle for the title slide (otherwise GPT comes up with one)")
+	fs.StringVar(&options.Footer, "footer", "", "text for a small footer on each content slide")
+	fs.StringVar(&options.Author, "author", "", "author name to put in the footer")
+	fs.BoolVar(&options.FooterDate, "footer-date", true, "include the day the deck was made in the footer")
+	fs.StringVar(&options.FooterPosition, "footer-position", "left", "which bottom corner the footer goes in: left or right")
+	fs.BoolVar(&options.PageNumbers, "page-numbers", false, "number the content slides like \"3 / 12\" in the bottom corner across from the footer")
+	fs.BoolVar(&options.NumberClosingSlide, "number-closing-slide", false, "with --page-numbers, number the closing slide too")
+}
+
+// validateOutlineOptions catches bad values for the outline flags before
+// we've spent any time or tokens on the document.
+func validateOutlineOptions() {
+	if options.ReadMode != "structured" && options.ReadMode != "export" {
+		fatalf("I don't know the read mode \"%s\". Try structured or export.", options.ReadMode)
+	}
+	if options.ExportMimeType != "text/plain" && options.ExportMimeType != "text/markdown" {
+		fatalf("Drive can export docs as text/plain or text/markdown, not \"%s\".", options.ExportMimeType)
+	}
+	if options.InputHeadings != "" {
+		if options.ReadMode == "export" {
+			fatalf("--input-headings needs the headings from --read-mode structured.")
+		}
+		for _, heading := range strings.Split(options.InputHeadings, ",") {
+			if strings.TrimSpace(heading) != "" {
+				options.inputHeadings = append(options.inputHeadings, strings.TrimSpace(heading))
+			}
+		}
+	}
+	if options.explicit["exact-slides"] {
+		if options.explicit["min-slides"] || options.explicit["max-slides"] {
+			fatalf("--exact-slides can't be used with --min-slides or --max-slides.")
+		}
+		if options.ExactSlides < 1 {
+			fatalf("--exact-slides needs to be at least 1.")
+		}
+	}
+	if options.DedupeThreshold <= 0 || options.DedupeThreshold > 1 {
+		fatalf("--dedupe-threshold needs to be more than 0 and at most 1.")
+	}
+	if options.NotesWords < 0 || (options.explicit["notes-words"] && options.NotesWords == 0) {
+		fatalf("--notes-words needs to be a positive number of words.")
+	}
+	if options.MinSlides < 1 || options.MaxSlides < options.MinSlides {
+		fatalf("--min-slides needs to be at least 1 and no more than --max-slides.")
+	}
+	if options.SplitOnHeadings && options.ReadMode == "export" {
+		fatalf("--split-on-headings needs the headings from --read-mode structured.")
+	}
+	if options.NoAI {
+		if options.ReadMode == "export" || len(options.inputHeadings) > 0 {
+			fatalf("--no-ai needs the whole doc from --read-mode structured, so it can't be used with --read-mode export or --input-headings.")
+		}
+		if options.AITitles || options.ExpandNotes || options.Refine || options.explicit["candidates"] || options.PromptFile != "" || options.NotesLang != "" {
+			fatalf("--no-ai doesn't ask GPT anything, so it can't be used with --ai-titles, --expand-notes, --refine, --candidates, --prompt-file, or --notes-lang.")
+		}
+	}
+	if options.FormatConfig != "" {
+		OUTLINE_FORMAT = loadOutlineFormat(options.FormatConfig)
+	}
+	// This has to come after the format config, since the template gets
+	// checked for its markers
+	if options.PromptFile != "" {
+		PROMPT_TEMPLATE = loadPromptTemplate(options.PromptFile)
+	}
+	if options.MaxTokensBudget < 0 || options.MaxCost < 0 {
+		fatalf("--max-tokens-budget and --max-cost can't be negative.")
+	}
+	if options.MaxTokens < 0 {
+		fatalf("--max-tokens can't be negative.")
+	}
+	if options.Candidates < 1 || options.Candidates > 10 {
+		fatalf("--candidates needs to be between 1 and 10.")
+	}
+	if options.MaxBullets < 0 {
+		fatalf("--max-bullets can't be negative.")
+	}
+	if options.ContinuationStyle != "" {
+		if !isOneOf(options.ContinuationStyle, CONTINUATION_STYLES) {
+			fatalf("I don't know the continuation style \"%s\". Try one of: %s", options.ContinuationStyle, strings.Join(CONTINUATION_STYLES, ", "))
+		}
+		if options.MaxBullets == 0 {
+			fatalf("--continuation-style only means something with --max-bullets.")
+		}
+		// The slides it adds would throw the count off again
+		if options.ExactSlides > 0 {
+			fatalf("--continuation-style adds slides, so it can't be used with --exact-slides (or a preset that sets it). Use --max-slides to cap the count instead.")
+		}
+	}
+}
+
+func isOneOf(value string, choices []string) bool {
+	for _, choice := range choices {
+		if choice == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateGenerateOptions() {
+	if options.WriteOutline != "" && options.FromOutline != "" {
+		fatalf("--write-outline and --from-outline don't make sense together.")
+	}
+	if options.FromFolder != "" && (options.Title != "" || options.FromClipboard) {
+		fatalf("--from-folder makes a deck for each doc, so --title and --from-clipboard don't make sense with it.")
+	}
+	if options.FromFolder != "" && (options.FromOutline != "" || options.WriteOutline != "" || options.PresentationId != "") {
+		fatalf("--from-folder makes a new deck for each doc, so it can't be used with --from-outline, --write-outline, or --presentation-id.")
+	}
+	// --watch can rebuild a whole deck in place, so it doesn't need a range
+	if (options.PresentationId == "") != (options.SlideRange == "") && !(options.Watch && options.SlideRange == "") {
+		fatalf("--presentation-id and --slide-range only work together.")
+	}
+	if options.ImportPptx != "" {
+		if options.PresentationId != "" || options.FromFolder != "" || options.Watch || options.ReplaceExisting {
+			fatalf("--import-pptx starts a new deck from the file, so it can't be used with --presentation-id, --from-folder, --watch, or --replace-existing.")
+		}
+		if !strings.EqualFold(filepath.Ext(options.ImportPptx), ".pptx") {
+			fatalf("--import-pptx needs a .pptx file, not \"%s\".", options.ImportPptx)
+		}
+		if _, err := os.Stat(options.ImportPptx); err != nil {
+			fatalf("Could not read %s: %s", options.ImportPptx, err)
+		}
+	} else if options.KeepPptx {
+		fatalf("--keep-pptx only means something with --import-pptx.")
+	}
+	// Only a new deck gets to pick its size
+	if options.explicit["size"] && (options.ImportPptx != "" || options.PresentationId != "") {
+		fatalf("--size only works on new decks, so it can't be used with --import-pptx or --presentation-id.")
+	}
+	if !isOneOf(options.Target, TARGETS) {
+		fatalf("I don't know the target \"%s\". Try one of: %s", options.Target, strings.Join(TARGETS, ", "))
+	}
+	if options.Target == "onedrive" {
+		if options.PresentationId != "" || options.FromFolder != "" || options.Watch {
+			fatalf("--target onedrive uploads one new deck, so it can't be used with --presentation-id, --from-folder, or --watch.")
+		}
+		if MS_CLIENT_ID == "" {
+			fatalf("--target onedrive needs MS_CLIENT_ID set to the app registration to sign in to Microsoft with.")
+		}
+		given := make([]string, 0)
+		for _, name := range ONEDRIVE_UNSUPPORTED_FLAGS {
+			if options.explicit[name] {
+				given = append(given, "--"+name)
+			}
+		}
+		if len(given) > 0 {
+			fatalf("--target onedrive builds the pptx without Google Slides or Drive, so it can't be used with %s.", strings.Join(given, ", "))
+		}
+	} else if options.OneDriveFolder != "" {
+		fatalf("--onedrive-folder only means something with --target onedrive.")
+	}
+	if options.Watch {
+		if options.FromOutline != "" || options.FromFolder != "" || options.WriteOutline != "" || options.DryRun {
+			fatalf("--watch regenerates the deck from a doc, so it can't be used with --from-outline, --from-folder, --write-outline, or --dry-run.")
+		}
+		if options.WatchInterval < time.Second {
+			fatalf("--interval needs to be at least a second.")
+		}
+	}
+	options.ThumbnailSize = strings.ToUpper(options.ThumbnailSize)
+	if !isOneOf(options.ThumbnailSize, THUMBNAIL_SIZES) {
+		fatalf("I don't know the thumbnail size \"%s\". Try one of: %s", options.ThumbnailSize, strings.Join(THUMBNAIL_SIZES, ", "))
+	}
+	if options.SlideRange != "" {
+		var err error
+		options.slideRangeStart, options.slideRangeEnd, err = parseSlideRange(options.SlideRange)
+		if err != nil {
+			fatalf("%s", err)
+		}
+	}
+	if !isOneOf(options.ExistingNotes, EXISTING_NOTES) {
+		fatalf("I don't know what to do with the existing notes for \"%s\". Try one of: %s", options.ExistingNotes, strings.Join(EXISTING_NOTES, ", "))
+	}
+}
+
+// validateSlideOptions catches bad values for the slide flags before we've
+// spent any time or tokens on the document.
+func validateSlideOptions() {
+	sources, err := parseImageSources(options.ImageSource)
+	if err != nil {
+		fatalf("%s", err)
+	}
+	options.imageSources = sources
+	if !isOneOf(options.ImageFit, IMAGE_FITS) {
+		fatalf("I don't know the image fit \"%s\". Try one of: %s", options.ImageFit, strings.Join(IMAGE_FITS, ", "))
+	}
+	if !isOneOf(options.ContentLayout, PREDEFINED_LAYOUTS) {
+		fatalf("I don't know the content layout \"%s\". Try one of: %s", options.ContentLayout, strings.Join(PREDEFINED_LAYOUTS, ", "))
+	}
+	if _, ok := THEMES[options.Theme]; options.Theme != "" && !ok {
+		fatalf("I don't know the theme \"%s\". Try one of: %s", options.Theme, themeNames())
+	}
+	if !isOneOf(options.Autofit, AUTOFITS) {
+		fatalf("I don't know the autofit \"%s\". Try one of: %s", options.Autofit, strings.Join(AUTOFITS, ", "))
+	}
+	if !isOneOf(options.BodyAlign, BODY_ALIGNS) {
+		fatalf("I don't know the body alignment \"%s\". Try one of: %s", options.BodyAlign, strings.Join(BODY_ALIGNS, ", "))
+	}
+	if !isOneOf(options.BodyValign, BODY_VALIGNS) {
+		fatalf("I don't know the vertical body alignment \"%s\". Try one of: %s", options.BodyValign, strings.Join(BODY_VALIGNS, ", "))
+	}
+	if !isOneOf(options.ClosingLayout, PREDEFINED_LAYOUTS) {
+		fatalf("I don't know the closing layout \"%s\". Try one of: %s", options.ClosingLayout, strings.Join(PREDEFINED_LAYOUTS, ", "))
+	}
+	if preset, ok := PAGE_SIZES[options.Size]; ok {
+		options.pageWidth, options.pageHeight = preset.Width, preset.Height
+	} else if options.Size == "custom" {
+		if options.PageWidth <= 0 || options.PageHeight <= 0 {
+			fatalf("--size custom needs a --page-width and --page-height of more than 0.")
+		}
+		options.pageWidth, options.pageHeight = float64(options.PageWidth), float64(options.PageHeight)
+	} else {
+		fatalf("I don't know the page size \"%s\". Try one of: %s", options.Size, pageSizeNames())
+	}
+	if options.Size != "custom" && (options.PageWidth != 0 || options.PageHeight != 0) {
+		fatalf("--page-width and --page-height only go with --size custom.")
+	}
+	if !isOneOf(options.TitleLayout, PREDEFINED_LAYOUTS) {
+		fatalf("I don't know the title layout \"%s\". Try one of: %s", options.TitleLayout, strings.Join(PREDEFINED_LAYOUTS, ", "))
+	}
+	if options.NoTitleSlide && options.explicit["title-layout"] {
+		fatalf("--title-layout and --no-title-slide don't make sense together.")
+	}
+	for _, source := range options.imageSources {
+		if options.NoAI && (source == "gpt" || source == "dalle") {
+			fatalf("--no-ai doesn't ask GPT anything, so it can't use --image-source %s.", source)
+		}
+	}
+	if options.QRURL != "" {
+		options.QRSource = true
+	}
+	for _, domain := range strings.Split(options.ImageAllowlist, ",") {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+			options.imageAllowlist = append(options.imageAllowlist, domain)
+		}
+	}
+	if options.BackgroundOnTitle && options.BackgroundImage == "" {
+		fatalf("--background-on-title needs a --background-image.")
+	}
+	if !isValidTransition(options.Transition) {
+		fatalf("I don't know the transition \"%s\".", options.Transition)
+	}
+	if options.FitMaxChars < 1 || options.FitMaxBullets < 1 {
+		fatalf("--fit-max-chars and --fit-max-bullets need to be at least 1.")
+	}
+	if options.FooterPosition != "left" && options.FooterPosition != "right" {
+		fatalf("The footer can go on the left or the right, not \"%s\".", options.FooterPosition)
+	}
+	if !isValidBulletGlyph(options.BulletGlyph) {
+		fatalf("I don't know the bullet glyph \"%s\". Try %s", options.BulletGlyph, bulletGlyphChoices())
+	}
+	options.NumberStyle = strings.ToUpper(options.NumberStyle)
+	if _, ok := NUMBERED_PRESETS[options.NumberStyle]; !ok {
+		fatalf("I don't know the number style \"%s\". Try %s", options.NumberStyle, numberStyleChoices())
+	}
+	if options.NumberedBullets && options.NoBullets {
+		fatalf("--numbered-bullets and --no-bullets don't make sense together.")
+	}
+	if options.NumberedBullets && options.explicit["bullet-glyph"] {
+		logln("WARNING: --numbered-bullets replaces the bullets, so --bullet-glyph is being ignored.")
+	}
+}
+
+// parseInterspersed lets flags show up before or after the positional args.
+// The stdlib flag package stops at the first non-flag, which makes
+// "generate <doc> --some-flag" silently ignore the flag.
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	positional := make([]string, 0)
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	options.explicit = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		options.explicit[f.Name] = true
+	})
+
+	return positional
+}
+
+func runGenerate(args []string) {
+	options.command = "generate"
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	fs.BoolVar(&options.SelfTest, "self-test", false, "parse the bundled exampleOutline.txt and build it against a fake Slides API, without calling any real ones")
+	fs.BoolVar(&options.SelfTestLive, "self-test-live", false, "like --self-test, but actually create the deck")
+	fs.BoolVar(&options.PrintURLOnly, "print-url-only", false, "print only the presentation URL on stdout and send everything else to stderr")
+	fs.BoolVar(&options.DryRun, "dry-run", false, "show the outline GPT came up with and stop without making any slides")
+	fs.BoolVar(&options.JSONResult, "json-result", false, "print a JSON summary of the run on stdout and send everything else to stderr")
+	fs.StringVar(&options.WriteOutline, "write-outline", "", "save the outline as JSON to this file and stop before making any slides")
+	fs.StringVar(&options.FromOutline, "from-outline", "", "build the deck from an outline saved with --write-outline instead of a document")
+	fs.BoolVar(&options.CheckImages, "check-images", false, "report which of the outline's image URLs actually work before building the deck")
+	fs.BoolVar(&options.FailOnDeadImages, "fail-on-dead-images", false, "with --check-images, stop if any image URL is broken")
+	fs.StringVar(&options.FromFolder, "from-folder", "", "make a deck for every Google Doc in this Drive folder")
+	fs.IntVar(&options.Concurrency, "concurrency", 2, "how many docs from --from-folder to work on at once")
+	fs.StringVar(&options.StateFile, "state-file", "folder_state.json", "where --from-folder remembers which docs it already made decks for")
+	fs.BoolVar(&options.Force, "force", false, "with --from-folder, remake decks even for docs that haven't changed")
+	fs.StringVar(&options.PresentationId, "presentation-id", "", "an existing deck to update instead of making a new one (needs --slide-range, or --watch to rebuild all of it)")
+	fs.StringVar(&options.SlideRange, "slide-range", "", "which content slides of --presentation-id to regenerate, like 3-5")
+	fs.StringVar(&options.ExistingNotes, "existing-notes", "keep", "what to do with the speaker notes on the slides --slide-range regenerates: keep them, merge the new notes in, or replace them")
+	fs.StringVar(&options.ImportPptx, "import-pptx", "", "a PowerPoint file to upload to Drive as Google Slides and add the new slides onto the end of")
+	fs.BoolVar(&options.KeepPptx, "keep-pptx", false, "with --import-pptx, also keep a copy of the original .pptx in Drive")
+	fs.StringVar(&options.Target, "target", "slides", "where the finished deck goes: slides, or onedrive (experimental) to build a plain pptx of the titles and bullets and upload it to OneDrive instead")
+	fs.StringVar(&options.OneDriveFolder, "onedrive-folder", "", "folder in OneDrive for --target onedrive, like Decks/2024 (defaults to the top of it)")
+	fs.StringVar(&options.Thumbnails, "thumbnails", "", "directory to save a PNG of every slide in once the deck is made")
+	fs.StringVar(&options.ThumbnailSize, "thumbnail-size", "MEDIUM", "size of the --thumbnails: "+strings.Join(THUMBNAIL_SIZES, ", "))
+	fs.BoolVar(&options.SpeakerDoc, "speaker-doc", false, "also make a Google Doc with each slide's title and speaker notes")
+	fs.BoolVar(&options.Watch, "watch", false, "keep checking the doc and regenerate the deck in place whenever it changes, until Ctrl+C")
+	fs.DurationVar(&options.WatchInterval, "interval", 30*time.Second, "how often --watch checks the doc for changes")
+	registerCommonFlags(fs)
+	registerOutlineFlags(fs)
+	registerSlideFlags(fs)
+	positional := parseInterspersed(fs, args)
+	applyCommonFlags(fs)
+	if options.PrintURLOnly || options.JSONResult {
+		LOG = os.Stderr
+	}
+	defer reportPanicAsJSON()
+	validateOutlineOptions()
+	validateSlideOptions()
+	validateGenerateOptions()
+	validateTextInput(positional)
+
+	logln("Here Comes Doctor Slides!")
+	if options.SelfTest || options.SelfTestLive {
+		runSelfTest(options.SelfTestLive)
+		return
+	}
+	if options.FromFolder != "" {
+		requireOpenAIKey()
+		generateFromFolder(options.FromFolder, options.Concurrency)
+		return
+	}
+	if options.Watch {
+		if len(positional) < 1 || readsText(positional) {
+			logln("I need a document ID to watch, fool.")
+			return
+		}
+		requireOpenAIKey()
+		watchDocument(positional[0])
+		return
+	}
+	var outline GPTOutline
+	if options.FromOutline != "" {
+		outline = readOutlineFile(options.FromOutline)
+	} else {
+		if len(positional) < 1 && !options.FromClipboard {
+			logln("I need a document ID to get started, fool.")
+			return
+		}
+		requireOpenAIKey()
+		outline = buildOutline(positional)
+	}
+	if options.CheckImages && !checkOutlineImages(outline) && options.FailOnDeadImages {
+		fatalf("Some of the image URLs are broken, so I'm stopping here.")
+	}
+	if options.WriteOutline != "" {
+		writeOutlineFile(outline, options.WriteOutline)
+		return
+	}
+	if options.DryRun {
+		renderOutlineTerminal(os.Stdout, outline, isTerminal(os.Stdout))
+		return
+	}
+	var presentationId, url string
+	if options.Target == "onedrive" {
+		url = writeToOneDrive(outline)
+	} else if options.PresentationId != "" {
+		presentationId, url = updateSlideRange(options.PresentationId, options.slideRangeStart, options.slideRangeEnd, outline)
+	} else {
+		presentationId, url = writeToSlides(outline)
+	}
+	if options.Thumbnails != "" {
+		saveThumbnails(presentationId, options.Thumbnails, options.ThumbnailSize)
+	}
+	var speakerDocURL string
+	if options.SpeakerDoc {
+		speakerDocURL = writeSpeakerDoc(outline)
+	}
+	if options.JSONResult {
+		printJSONResult(presentationId, url, speakerDocURL, len(outline.Slides))
+	} else if options.PrintURLOnly {
+		fmt.Println(url)
+	}
+}
+
+func runExport(args []string) {
+	options.command = "export"
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	fs.StringVar(&options.ExportFormat, "format", "md", "export format: pptx (titles and bullets only), html, or md")
+	fs.StringVar(&options.ExportOutput, "out", "", "file to write the export to (defaults to the document title)")
+	registerCommonFlags(fs)
+	registerOutlineFlags(fs)
+	registerSlideFlags(fs)
+	positional := parseInterspersed(fs, args)
+	applyCommonFlags(fs)
+	validateOutlineOptions()
+	validateSlideOptions()
+
+	validateTextInput(positional)
+
+	logln("Here Comes Doctor Slides!")
+	if len(positional) < 1 && !options.FromClipboard {
+		logln("I need a document ID to get started, fool.")
+		return
+	}
+	if !isValidExportFormat(options.ExportFormat) {
+		fatalf("I don't know how to export \"%s\". Try pptx, html, or md.", options.ExportFormat)
+	}
+	requireOpenAIKey()
+	outline := buildOutline(positional)
+	exportOutline(outline, options.ExportFormat, options.ExportOutput)
+}
+
+func runListLayouts(args []string) {
+	options.command = "list-layouts"
+	fs := flag.NewFlagSet("list-layouts", flag.ExitOnError)
+	fs.StringVar(&options.PresentationId, "presentation-id", "", "the deck to list the layouts of (defaults to a new empty one)")
+	registerCommonFlags(fs)
+	positional := parseInterspersed(fs, args)
+	applyCommonFlags(fs)
+	if len(positional) > 0 {
+		fatalf("list-layouts doesn't take a document, try --presentation-id.")
+	}
+	listLayouts(options.PresentationId)
+}
+
+func runDoctor(args []string) {
+	options.command = "doctor"
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	registerCommonFlags(fs)
+	parseInterspersed(fs, args)
+	applyCommonFlags(fs)
+	if broken := runDoctorChecks(); broken > 0 {
+		fatalf("%d of the essential checks failed.", broken)
+	}
+	logln("Everything checks out.")
+}
+
+func runAuth(args []string) {
+	if len(args) < 1 || args[0] != "login" {
+		fatalf("The only auth command right now is \"auth login\".")
+	}
+	options.command = "auth"
+	scopes := requiredScopes()
+	config := getGoogleConfig(scopes)
+	tok := getTokenFromWeb(config)
+	if err := saveToken(TOKEN_FILE, tok, scopes); err != nil {
+		fatalf("%s", err)
+	}
+	logln("You're logged in. Go make some slides.")
+}
@@ -0,0 +1,121 @@
+package doctorslides
+
+import (
+	"google.golang.org/api/slides/v1"
+	"strings"
+)
+
+// CODE_FENCE opens and closes a code block inside a slide, the same as in
+// markdown
+const CODE_FENCE = "```"
+
+// How code gets shown: a monospace font on a light tint, a bit smaller than
+// the body text so a reasonable snippet fits
+const (
+	CODE_FONT      = "Courier New"
+	CODE_FONT_SIZE = 12
+)
+
+var CODE_BACKGROUND = &slides.RgbColor{Red: 0.95, Green: 0.95, Blue: 0.95}
+
+// codeLine strips the fence's own indentation off a line of code, so the code
+// keeps its indentation relative to the fence and nothing more
+func codeLine(line string, fenceIndent string) string {
+	return strings.TrimPrefix(strings.TrimRight(line, "\r"), fenceIndent)
+}
+
+// fenceIndent is whatever whitespace comes before the fence on its line
+func fenceIndent(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// codePlacement puts the code in the body area. When the slide has bullets
+// too they keep the top of it and the code takes the bottom half.
+func codePlacement(withBullets bool) ImagePlacement {
+	body := bodyBox()
+	if !withBullets {
+		return body
+	}
+	placement := body
+	placement.Height = body.Height / 2
+	placement.Y = body.Y + placement.Height
+
+	return placement
+}
+
+func codeBoxId(slide *slides.Page) string {
+	return slide.ObjectId + "_code"
+}
+
+// buildCodeRequests puts a slide's code in a box of its own. It never gets
+// bullets, and the text goes in as is so the line breaks and indentation
+// come out exactly as written.
+func buildCodeRequests(slide *slides.Page, code string, withBullets bool) []*slides.Request {
+	boxId := codeBoxId(slide)
+
+	return []*slides.Request{
+		{
+			CreateShape: &slides.CreateShapeRequest{
+				ObjectId:          boxId,
+				ShapeType:         "TEXT_BOX",
+				ElementProperties: elementProperties(slide.ObjectId, codePlacement(withBullets)),
+			},
+		},
+		{
+			InsertText: &slides.InsertTextRequest{
+				ObjectId: boxId,
+				Text:     code,
+			},
+		},
+		{
+			UpdateTextStyle: &slides.UpdateTextStyleRequest{
+				ObjectId: boxId,
+				TextRange: &slides.Range{
+					Type: "ALL",
+				},
+				Style: &slides.TextStyle{
+					FontFamily: CODE_FONT,
+					FontSize: &slides.Dimension{
+						Magnitude: CODE_FONT_SIZE,
+						Unit:      "PT",
+					},
+				},
+				Fields: "fontFamily,fontSize",
+			},
+		},
+		{
+			UpdateShapeProperties: &slides.UpdateShapePropertiesRequest{
+				ObjectId: boxId,
+				ShapeProperties: &slides.ShapeProperties{
+					ShapeBackgroundFill: &slides.ShapeBackgroundFill{
+						SolidFill: &slides.SolidFill{
+							Color: &slides.OpaqueColor{
+								RgbColor: CODE_BACKGROUND,
+							},
+						},
+					},
+				},
+				Fields: "shapeBackgroundFill.solidFill.color",
+			},
+		},
+	}
+}
+
+// buildRemoveCodeRequests gets rid of the code box an earlier run made, so an
+// update can put the new code in its place
+func buildRemoveCodeRequests(slide *slides.Page) []*slides.Request {
+	boxId := codeBoxId(slide)
+	for _, element := range slide.PageElements {
+		if element.ObjectId == boxId {
+			return []*slides.Request{
+				{
+					DeleteObject: &slides.DeleteObjectRequest{
+						ObjectId: boxId,
+					},
+				},
+			}
+		}
+	}
+
+	return nil
+}
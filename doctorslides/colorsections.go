@@ -0,0 +1,103 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/slides/v1"
+)
+
+// SECTION_PALETTE are the accent colors --color-sections goes through, one
+// per section, starting over once they run out
+var SECTION_PALETTE = []*slides.RgbColor{
+	rgb(0x1a73e8),
+	rgb(0xe8710a),
+	rgb(0x188038),
+	rgb(0xa142f4),
+	rgb(0xd93025),
+	rgb(0x129eaf),
+}
+
+// How wide the accent bar down the left edge of each slide is, in EMU
+const SECTION_BAR_WIDTH = 137160
+
+// sectionColors gives each content slide its section's color, or nil for a
+// slide that doesn't say what section it's in. Sections are numbered in the
+// order they first come up.
+func sectionColors(outline GPTOutline) []*slides.RgbColor {
+	colors := make([]*slides.RgbColor, len(outline.Slides))
+	if !options.ColorSections {
+		return colors
+	}
+	seen := make(map[string]int)
+	for i, slide := range outline.Slides {
+		if slide.Section == "" {
+			continue
+		}
+		index, ok := seen[slide.Section]
+		if !ok {
+			index = len(seen)
+			seen[slide.Section] = index
+		}
+		colors[i] = SECTION_PALETTE[index%len(SECTION_PALETTE)]
+	}
+	if len(seen) == 0 {
+		logln("--color-sections didn't find any sections in the outline, so the slides are staying the one color. Try it with --split-on-headings.")
+	} else if DEBUG {
+		logf("Coloring %d sections\n", len(seen))
+	}
+
+	return colors
+}
+
+// buildSectionColorRequests tints the title and runs a bar down the left edge
+// in the section's color. It has to come after the title text goes in, since
+// Slides won't style text that isn't there yet.
+func buildSectionColorRequests(slide *slides.Page, index int, color *slides.RgbColor) []*slides.Request {
+	_, titleId := findTextBox(slide, "title", "TITLE", "CENTERED_TITLE")
+	_, pageHeight := pageSize()
+	barId := fmt.Sprintf("section_bar_%d", index)
+
+	return []*slides.Request{
+		{
+			UpdateTextStyle: &slides.UpdateTextStyleRequest{
+				ObjectId: titleId,
+				TextRange: &slides.Range{
+					Type: "ALL",
+				},
+				Style: &slides.TextStyle{
+					ForegroundColor: &slides.OptionalColor{
+						OpaqueColor: &slides.OpaqueColor{RgbColor: color},
+					},
+				},
+				Fields: "foregroundColor",
+			},
+		},
+		{
+			CreateShape: &slides.CreateShapeRequest{
+				ObjectId:  barId,
+				ShapeType: "RECTANGLE",
+				ElementProperties: elementProperties(slide.ObjectId, ImagePlacement{
+					X:      0,
+					Y:      0,
+					Width:  SECTION_BAR_WIDTH,
+					Height: pageHeight,
+				}),
+			},
+		},
+		{
+			UpdateShapeProperties: &slides.UpdateShapePropertiesRequest{
+				ObjectId: barId,
+				ShapeProperties: &slides.ShapeProperties{
+					ShapeBackgroundFill: &slides.ShapeBackgroundFill{
+						SolidFill: &slides.SolidFill{
+							Color: &slides.OpaqueColor{RgbColor: color},
+						},
+					},
+					Outline: &slides.Outline{
+						PropertyState: "NOT_RENDERED",
+					},
+				},
+				Fields: "shapeBackgroundFill.solidFill.color,outline.propertyState",
+			},
+		},
+	}
+}
@@ -0,0 +1,36 @@
+package doctorslides
+
+import (
+	"strings"
+	"unicode"
+)
+
+// estimateTokens is a rough count of the GPT tokens in some text. English
+// averages about four characters a token, which is close enough to see how
+// much a change saves.
+func estimateTokens(text string) int {
+	return (len([]rune(text)) + 3) / 4
+}
+
+// compactText trims the trailing space off every line and squeezes runs of
+// blank lines down to one. Paragraphs stay on their own lines, so GPT still
+// sees where one ends and the next begins.
+func compactText(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if line == "" {
+			if blank || len(kept) == 0 {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		kept = append(kept, line)
+	}
+
+	return strings.TrimRight(strings.Join(kept, "\n"), "\n") + "\n"
+}
@@ -0,0 +1,129 @@
+package doctorslides
+
+import (
+	"strings"
+	"unicode"
+)
+
+// CONCISE_LEADING_PHRASES are the throat clearing GPT likes to start a
+// bullet with. They're checked in order, so longer ones go first.
+var CONCISE_LEADING_PHRASES = []string{
+	"it is important to note that",
+	"it's important to note that",
+	"it is worth noting that",
+	"it is important to",
+	"it's important to",
+	"keep in mind that",
+	"remember that",
+	"note that",
+	"there are",
+	"there is",
+	"you can",
+	"you should",
+	"we will",
+	"we can",
+	"this is",
+	"the",
+	"a",
+	"an",
+}
+
+// CONCISE_FILLER_WORDS get dropped wherever they turn up in a bullet
+var CONCISE_FILLER_WORDS = map[string]bool{
+	"actually":    true,
+	"basically":   true,
+	"essentially": true,
+	"just":        true,
+	"quite":       true,
+	"really":      true,
+	"simply":      true,
+	"very":        true,
+}
+
+// CONCISE_MAX_WORDS is how long a bullet can be before it gets cut at its
+// first clause
+const CONCISE_MAX_WORDS = 8
+
+// conciseOutlineBullets rewrites every bullet as a short phrase instead of a
+// sentence. When the deck has notes the whole sentence goes into them, so
+// nothing the bullet said is lost.
+func conciseOutlineBullets(outline GPTOutline, keepInNotes bool) GPTOutline {
+	wordsBefore, wordsAfter, count := 0, 0, 0
+	for i := range outline.Slides {
+		slide := &outline.Slides[i]
+		kept := make([]string, 0)
+		for j, bullet := range slide.Bullets {
+			short := conciseBullet(bullet.Text)
+			wordsBefore += len(strings.Fields(bullet.Text))
+			wordsAfter += len(strings.Fields(short))
+			count++
+			if short == bullet.Text {
+				continue
+			}
+			// The notes might already have the whole bullet if they were
+			// made from the bullets
+			if keepInNotes && !strings.Contains(slide.Notes, bullet.Text) {
+				kept = append(kept, bullet.Text)
+			}
+			slide.Bullets[j].Text = short
+			// The emphasis was worked out for the old text
+			slide.Bullets[j].Emphasis = nil
+		}
+		if len(kept) > 0 {
+			slide.Notes = strings.TrimSpace(slide.Notes + "\n" + strings.Join(kept, "\n"))
+		}
+	}
+	if DEBUG && count > 0 {
+		logf("Concise bullets went from %.1f words a bullet to %.1f\n", float64(wordsBefore)/float64(count), float64(wordsAfter)/float64(count))
+	}
+
+	return outline
+}
+
+// conciseBullet cuts a bullet down to its key words: the filler comes out,
+// anything past the first clause of a long bullet goes, and so does the
+// full stop at the end. A bullet that would end up empty is left alone.
+func conciseBullet(bullet string) string {
+	words := strings.Fields(bullet)
+	for stripped := true; stripped; {
+		stripped = false
+		for _, phrase := range CONCISE_LEADING_PHRASES {
+			phraseWords := strings.Fields(phrase)
+			if len(words) > len(phraseWords) && strings.EqualFold(strings.Join(words[:len(phraseWords)], " "), phrase) {
+				words = words[len(phraseWords):]
+				stripped = true
+				break
+			}
+		}
+	}
+	kept := make([]string, 0, len(words))
+	for _, word := range words {
+		bare := strings.ToLower(strings.TrimFunc(word, unicode.IsPunct))
+		if CONCISE_FILLER_WORDS[bare] {
+			continue
+		}
+		kept = append(kept, word)
+	}
+	// Long bullets keep only their first clause, as long as that's enough
+	// to still say something
+	if len(kept) > CONCISE_MAX_WORDS {
+		for i, word := range kept[:len(kept)-1] {
+			if i >= 2 && strings.ContainsAny(word[len(word)-1:], ",;:") {
+				kept = kept[:i+1]
+				break
+			}
+			if i >= 2 && (word == "-" || word == "–" || word == "—") {
+				kept = kept[:i]
+				break
+			}
+		}
+	}
+	short := strings.TrimRight(strings.Join(kept, " "), ".,;:!")
+	if short == "" {
+		return bullet
+	}
+	runes := []rune(short)
+	runes[0] = unicode.ToUpper(runes[0])
+
+	return string(runes)
+}
@@ -0,0 +1,86 @@
+package doctorslides
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CONTINUATION_STYLES are how --continuation-style titles the slides a
+// crowded slide gets split into: "counter" numbers them all like (2/3),
+// "cont" marks the ones after the first with (cont.), and "same" leaves the
+// title alone on all of them
+var CONTINUATION_STYLES = []string{"counter", "cont", "same"}
+
+// splitOutlineBullets is the other way to handle --max-bullets. Instead of
+// losing the bullets past the limit, the slide keeps going on as many more
+// slides as it takes. The notes, image, and anything else on the slide stay
+// with the first one.
+//
+// The extra slides still count against maxSlides, if there is one. Once
+// they've used up the room, crowded slides get cut down the same way
+// capOutlineBullets does it.
+func splitOutlineBullets(outline GPTOutline, limit int, style string, maxSlides int, keepInNotes bool) GPTOutline {
+	room := -1
+	if maxSlides > 0 {
+		room = maxSlides - len(outline.Slides)
+		if room < 0 {
+			room = 0
+		}
+	}
+	cut := 0
+	split := make([]SimpleSlide, 0, len(outline.Slides))
+	for _, slide := range outline.Slides {
+		if len(slide.Bullets) <= limit {
+			split = append(split, slide)
+			continue
+		}
+		parts := (len(slide.Bullets) + limit - 1) / limit
+		if room >= 0 {
+			if parts-1 > room {
+				parts = room + 1
+				if keepInNotes {
+					dropped := bulletTexts(slide.Bullets[parts*limit:])
+					slide.Notes = strings.TrimSpace(slide.Notes + "\n" + strings.Join(dropped, "\n"))
+				}
+				slide.Bullets = slide.Bullets[:parts*limit]
+				cut++
+			}
+			room -= parts - 1
+		}
+		for part := 0; part < parts; part++ {
+			end := (part + 1) * limit
+			if end > len(slide.Bullets) {
+				end = len(slide.Bullets)
+			}
+			piece := SimpleSlide{Section: slide.Section, Background: slide.Background}
+			if part == 0 {
+				piece = slide
+			}
+			piece.Title = continuationTitle(slide.Title, part, parts, style)
+			piece.Bullets = slide.Bullets[part*limit : end]
+			split = append(split, piece)
+		}
+	}
+	if len(split) > len(outline.Slides) && DEBUG {
+		logf("--max-bullets split the outline from %d slides into %d\n", len(outline.Slides), len(split))
+	}
+	if cut > 0 {
+		logf("There wasn't room under --max-slides to carry on %d crowded slides, so their last bullets were cut\n", cut)
+	}
+	outline.Slides = split
+
+	return outline
+}
+
+func continuationTitle(title string, part int, parts int, style string) string {
+	switch style {
+	case "counter":
+		return fmt.Sprintf("%s (%d/%d)", title, part+1, parts)
+	case "cont":
+		if part > 0 {
+			return title + " (cont.)"
+		}
+	}
+
+	return title
+}
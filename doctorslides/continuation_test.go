@@ -0,0 +1,49 @@
+package doctorslides
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitOutlineBullets(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxSlides int
+		want      []string
+		notes     string
+	}{
+		{
+			name: "no cap",
+			want: []string{"Plans (cont.)", "Plans (cont.)", "Team"},
+		},
+		{
+			name:      "the cap leaves room for one more",
+			maxSlides: 3,
+			want:      []string{"Plans (cont.)", "Team"},
+			notes:     "Three",
+		},
+		{
+			name:      "already at the cap",
+			maxSlides: 2,
+			want:      []string{"Team"},
+			notes:     "Two\nThree",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useDefaultOptions(t)
+			outline := GPTOutline{Slides: []SimpleSlide{
+				bulletSlide("Plans", "One", "Two", "Three"),
+				bulletSlide("Team", "Ann"),
+			}}
+			outline = splitOutlineBullets(outline, 1, "cont", test.maxSlides, true)
+			want := append([]string{"Plans"}, test.want...)
+			if got := slideTitles(outline); strings.Join(got, ", ") != strings.Join(want, ", ") {
+				t.Errorf("got slides %q, want %q", got, want)
+			}
+			if outline.Slides[0].Notes != test.notes {
+				t.Errorf("the first slide's notes were %q, want %q", outline.Slides[0].Notes, test.notes)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package doctorslides
+
+import (
+	"strings"
+	"unicode"
+)
+
+// dedupeOutlineBullets drops any bullet that says about the same thing as an
+// earlier one on its slide, keeping the first. Only bullets at the same
+// level get compared, so a sub-bullet repeating its parent stays put.
+func dedupeOutlineBullets(outline GPTOutline, threshold float64) GPTOutline {
+	removed := 0
+	for i := range outline.Slides {
+		slide := &outline.Slides[i]
+		kept := make([]Bullet, 0, len(slide.Bullets))
+		for _, bullet := range slide.Bullets {
+			duplicate := false
+			for _, earlier := range kept {
+				if earlier.Level == bullet.Level && bulletSimilarity(earlier.Text, bullet.Text) >= threshold {
+					duplicate = true
+					break
+				}
+			}
+			if duplicate {
+				if DEBUG {
+					logf("Dropping \"%s\" from \"%s\", it repeats an earlier bullet\n", bullet.Text, slide.Title)
+				}
+				removed++
+				continue
+			}
+			kept = append(kept, bullet)
+		}
+		slide.Bullets = kept
+	}
+	if removed > 0 {
+		logf("Bullets taken out for repeating others on the same slide: %d\n", removed)
+	}
+
+	return outline
+}
+
+// bulletSimilarity is how much two bullets' words overlap, from 0 for
+// nothing in common to 1 for the same words, ignoring case, punctuation,
+// and order
+func bulletSimilarity(a string, b string) float64 {
+	wordsA := bulletWords(a)
+	wordsB := bulletWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+	shared := 0
+	for word := range wordsA {
+		if wordsB[word] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(wordsA)+len(wordsB)-shared)
+}
+
+// DEDUPE_STOP_WORDS are too common to say anything about whether two
+// bullets match
+var DEDUPE_STOP_WORDS = map[string]bool{
+	"and": true, "are": true, "but": true, "can": true, "for": true, "from": true,
+	"has": true, "have": true, "its": true, "that": true, "the": true, "this": true,
+	"was": true, "were": true, "will": true, "with": true, "you": true, "your": true,
+}
+
+// bulletWords is the set of words in a bullet that count for comparing it.
+// Short and common words are left out, and a plural s comes off so "reduce"
+// and "reduces" match.
+func bulletWords(text string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if len([]rune(word)) <= 2 || DEDUPE_STOP_WORDS[word] {
+			continue
+		}
+		if len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+			word = strings.TrimSuffix(word, "s")
+		}
+		words[word] = true
+	}
+
+	return words
+}
@@ -0,0 +1,50 @@
+package doctorslides
+
+import (
+	"testing"
+)
+
+func TestDedupeOutlineBullets(t *testing.T) {
+	tests := []struct {
+		name    string
+		bullets []Bullet
+		want    []string
+	}{
+		{
+			name:    "reworded repeat",
+			bullets: []Bullet{{Text: "Caching reduces costs"}, {Text: "Reduce costs by caching"}},
+			want:    []string{"Caching reduces costs"},
+		},
+		{
+			name:    "different points",
+			bullets: []Bullet{{Text: "Caching reduces costs"}, {Text: "Hire two more engineers"}},
+			want:    []string{"Caching reduces costs", "Hire two more engineers"},
+		},
+		{
+			name:    "sharing a word isn't enough",
+			bullets: []Bullet{{Text: "Caching reduces costs"}, {Text: "Caching needs more memory"}},
+			want:    []string{"Caching reduces costs", "Caching needs more memory"},
+		},
+		{
+			name:    "sub-bullets aren't compared with their parent",
+			bullets: []Bullet{{Text: "Caching reduces costs"}, {Text: "Reduce costs by caching", Level: 1}},
+			want:    []string{"Caching reduces costs", "Reduce costs by caching"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useDefaultOptions(t)
+			outline := dedupeOutlineBullets(GPTOutline{Slides: []SimpleSlide{{Title: "Costs", Bullets: test.bullets}}}, options.DedupeThreshold)
+			got := outline.Slides[0].Bullets
+			if len(got) != len(test.want) {
+				t.Fatalf("kept %+v, want %q", got, test.want)
+			}
+			for i := range test.want {
+				if got[i].Text != test.want[i] {
+					t.Errorf("kept %+v, want %q", got, test.want)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package doctorslides
+
+import (
+	"fmt"
+	"golang.org/x/oauth2/google"
+	"os"
+	"strings"
+)
+
+// DoctorCheck is one line of the doctor checklist. Essential ones failing
+// means nothing is going to work, the rest only matter for some flags.
+type DoctorCheck struct {
+	Name      string
+	Essential bool
+	Run       func() (string, error)
+}
+
+var DOCTOR_CHECKS = []DoctorCheck{
+	{Name: "credentials.json", Essential: true, Run: checkCredentials},
+	{Name: "Google sign in", Essential: true, Run: checkToken},
+	{Name: "OpenAI key", Essential: true, Run: checkOpenAI},
+	{Name: "Image source keys", Essential: false, Run: checkImageKeys},
+}
+
+// runDoctorChecks goes through the setup and says what's wrong with it,
+// without signing in, saving anything, or making a deck. It hands back how
+// many of the essential checks failed.
+func runDoctorChecks() int {
+	broken := 0
+	for _, check := range DOCTOR_CHECKS {
+		detail, err := check.Run()
+		status := "[ok]  "
+		if err != nil {
+			detail = err.Error()
+			status = "[warn]"
+			if check.Essential {
+				status = "[FAIL]"
+				broken++
+			}
+		}
+		fmt.Printf("%s %s: %s\n", status, check.Name, detail)
+	}
+
+	return broken
+}
+
+func checkCredentials() (string, error) {
+	credsBytes, err := os.ReadFile(CREDENTIALS_FILE)
+	if err != nil {
+		return "", fmt.Errorf("could not read %s. Download an OAuth client for a desktop app from the Google Cloud console and save it there", CREDENTIALS_FILE)
+	}
+	_, err = google.ConfigFromJSON(credsBytes)
+	if err != nil {
+		return "", fmt.Errorf("%s isn't an OAuth client file: %s", CREDENTIALS_FILE, err)
+	}
+
+	return CREDENTIALS_FILE + " looks good", nil
+}
+
+// checkToken makes sure the saved token covers what a plain generate needs
+// and that Google still takes it. Refreshing it here doesn't save the new
+// one, so the file is left as it was.
+func checkToken() (string, error) {
+	scopes := requiredScopes()
+	tok, granted, err := tokenFromFile(TOKEN_FILE, scopes)
+	if err == errScopeMismatch {
+		return "", fmt.Errorf("the saved token is missing some of %s. Run \"doctor_slides auth login\" to sign in again", strings.Join(scopes, ", "))
+	}
+	if err != nil {
+		return "", fmt.Errorf("there's no usable %s. Run \"doctor_slides auth login\" to sign in", TOKEN_FILE)
+	}
+	if _, err := checkCredentials(); err != nil {
+		return "", fmt.Errorf("can't check the token without a working %s", CREDENTIALS_FILE)
+	}
+	_, err = getGoogleConfig(granted).TokenSource(googleContext(), tok).Token()
+	if err != nil {
+		return "", fmt.Errorf("Google turned the saved token down (%s). Run \"doctor_slides auth login\" to sign in again", err)
+	}
+
+	return fmt.Sprintf("signed in with %d scopes", len(granted)), nil
+}
+
+func checkOpenAI() (string, error) {
+	if openAIKey() == "" {
+		return "", fmt.Errorf("OPEN_AI_KEY isn't set. Put it in .env or the environment (or use --no-ai)")
+	}
+	models, err := newOpenAIClient().ListModels(CTX)
+	if err != nil {
+		return "", fmt.Errorf("OpenAI didn't accept the key: %s", err)
+	}
+
+	return fmt.Sprintf("the key works and can see %d models (organization %s, project %s)", len(models.Models), accountOrDefault(OPENAI_ORG_ID), accountOrDefault(OPENAI_PROJECT)), nil
+}
+
+func checkImageKeys() (string, error) {
+	missing := make([]string, 0)
+	if UNSPLASH_ACCESS_KEY == "" {
+		missing = append(missing, "UNSPLASH_ACCESS_KEY (--image-source unsplash)")
+	}
+	if PEXELS_API_KEY == "" {
+		missing = append(missing, "PEXELS_API_KEY (--image-source pexels)")
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("not set: %s", strings.Join(missing, ", "))
+	}
+
+	return "Unsplash and Pexels keys are set", nil
+}
@@ -0,0 +1,95 @@
+package doctorslides
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// EMOJI_KEYWORDS matches words in a bullet to the emoji that goes in front
+// of it. A keyword matches any word that starts with it, so "cost" covers
+// "costs" and "costly" too.
+var EMOJI_KEYWORDS = map[string]string{
+	"cost":     "💰",
+	"budget":   "💰",
+	"price":    "💰",
+	"revenue":  "📈",
+	"growth":   "📈",
+	"time":     "⏱️",
+	"deadline": "⏱️",
+	"schedule": "📅",
+	"risk":     "⚠️",
+	"security": "🔒",
+	"team":     "👥",
+	"customer": "🙋",
+	"goal":     "🎯",
+	"idea":     "💡",
+	"data":     "📊",
+	"test":     "🧪",
+	"launch":   "🚀",
+	"global":   "🌍",
+}
+
+// loadEmojiKeywords reads a JSON object of keyword to emoji from
+// --emoji-map, which replaces the built in table entirely
+func loadEmojiKeywords(path string) map[string]string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fatalf("Could not read the emoji map: %s", err)
+	}
+	keywords := make(map[string]string)
+	err = json.Unmarshal(content, &keywords)
+	if err != nil {
+		fatalf("The emoji map %s isn't a valid JSON object: %s", path, err)
+	}
+	lowered := make(map[string]string)
+	for keyword, emoji := range keywords {
+		lowered[strings.ToLower(keyword)] = emoji
+	}
+
+	return lowered
+}
+
+// addEmojiBullets puts an emoji in front of every bullet with a keyword in
+// it. Longer keywords get checked first so the more specific one wins, and
+// the order of the words in the bullet decides between the rest.
+func addEmojiBullets(outline GPTOutline, keywords map[string]string) GPTOutline {
+	sorted := make([]string, 0, len(keywords))
+	for keyword := range keywords {
+		sorted = append(sorted, keyword)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	for i := range outline.Slides {
+		for j, bullet := range outline.Slides[i].Bullets {
+			if emoji := bulletEmoji(bullet.Text, sorted, keywords); emoji != "" {
+				outline.Slides[i].Bullets[j].Text = emoji + " " + bullet.Text
+				outline.Slides[i].Bullets[j].Emphasis = shiftEmphasis(bullet.Emphasis, len([]rune(emoji+" ")))
+			}
+		}
+	}
+
+	return outline
+}
+
+func bulletEmoji(text string, sorted []string, keywords map[string]string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		for _, keyword := range sorted {
+			if strings.HasPrefix(word, keyword) {
+				return keywords[keyword]
+			}
+		}
+	}
+
+	return ""
+}
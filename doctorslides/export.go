@@ -0,0 +1,208 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/drive/v3"
+	"html"
+	"io"
+	"os"
+	"strings"
+)
+
+const PPTX_MIME_TYPE = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+
+func isValidExportFormat(format string) bool {
+	switch format {
+	case "pptx", "html", "md":
+		return true
+	}
+
+	return false
+}
+
+func exportOutline(outline GPTOutline, format string, path string) {
+	if path == "" {
+		path = fmt.Sprintf("%s.%s", safeFileName(outline.Title), format)
+	}
+
+	var content []byte
+	switch format {
+	case "md":
+		content = []byte(renderOutlineMarkdown(outline))
+	case "html":
+		content = []byte(renderOutlineHTML(outline))
+	case "pptx":
+		// The same plain pptx --target onedrive uploads, so nothing gets
+		// made in Google Slides just to be downloaded again
+		logPptxLeftOff(outline, "the pptx export")
+		var err error
+		content, err = buildPptx(outline)
+		if err != nil {
+			logln("Could not build the pptx")
+			panic(err)
+		}
+	}
+
+	err := os.WriteFile(path, content, 0644)
+	if err != nil {
+		logln("Could not write the export")
+		panic(err)
+	}
+
+	logf("Exported %s to: %s\n", format, path)
+}
+
+func renderOutlineMarkdown(outline GPTOutline) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", outline.Title)
+	for _, slide := range outline.Slides {
+		fmt.Fprintf(&b, "\n## %s\n\n", slide.Title)
+		for _, bullet := range slide.Bullets {
+			fmt.Fprintf(&b, "%s- %s%s\n", strings.Repeat("  ", bullet.Level), checkboxMarker(bullet), bullet.Text)
+		}
+		if len(slide.Table) > 0 {
+			writeMarkdownTable(&b, slide.Table)
+		}
+		if slide.Code != "" {
+			fmt.Fprintf(&b, "\n%s\n%s\n%s\n", CODE_FENCE, slide.Code, CODE_FENCE)
+		}
+		if slide.Image != "" {
+			fmt.Fprintf(&b, "\n![%s](%s)\n", slide.Title, slide.Image)
+		}
+		if slide.Video != "" {
+			fmt.Fprintf(&b, "\n[Video](%s)\n", slide.Video)
+		}
+	}
+
+	return b.String()
+}
+
+func renderOutlineHTML(outline GPTOutline) string {
+	var b strings.Builder
+	title := html.EscapeString(outline.Title)
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n", title)
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", title)
+	for _, slide := range outline.Slides {
+		b.WriteString("<section>\n")
+		fmt.Fprintf(&b, "<h2>%s</h2>\n", html.EscapeString(slide.Title))
+		writeHTMLBullets(&b, slide.Bullets)
+		if len(slide.Table) > 0 {
+			writeHTMLTable(&b, slide.Table)
+		}
+		if slide.Code != "" {
+			fmt.Fprintf(&b, "<pre><code>%s</code></pre>\n", html.EscapeString(slide.Code))
+		}
+		if slide.Image != "" {
+			fmt.Fprintf(&b, "<img src=\"%s\" alt=\"%s\">\n", html.EscapeString(slide.Image), html.EscapeString(slide.Title))
+		}
+		if slide.Video != "" {
+			fmt.Fprintf(&b, "<p><a href=\"%s\">Video</a></p>\n", html.EscapeString(slide.Video))
+		}
+		b.WriteString("</section>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+
+	return b.String()
+}
+
+// writeHTMLBullets opens and closes nested lists as the bullet levels change
+func writeHTMLBullets(b *strings.Builder, bullets []Bullet) {
+	depth := 0
+	b.WriteString("<ul>\n")
+	for _, bullet := range bullets {
+		for depth < bullet.Level {
+			b.WriteString("<ul>\n")
+			depth++
+		}
+		for depth > bullet.Level {
+			b.WriteString("</ul>\n")
+			depth--
+		}
+		fmt.Fprintf(b, "<li>%s</li>\n", html.EscapeString(bullet.Text))
+	}
+	for ; depth > 0; depth-- {
+		b.WriteString("</ul>\n")
+	}
+	b.WriteString("</ul>\n")
+}
+
+// writeMarkdownTable uses the first row as the header, which is how GPT is
+// asked to lay tables out
+func writeMarkdownTable(b *strings.Builder, rows [][]string) {
+	b.WriteString("\n")
+	for i, row := range rows {
+		fmt.Fprintf(b, "| %s |\n", strings.Join(row, " | "))
+		if i == 0 {
+			fmt.Fprintf(b, "|%s\n", strings.Repeat(" --- |", len(row)))
+		}
+	}
+}
+
+func writeHTMLTable(b *strings.Builder, rows [][]string) {
+	b.WriteString("<table>\n")
+	for i, row := range rows {
+		cellTag := "td"
+		if i == 0 {
+			cellTag = "th"
+		}
+		b.WriteString("<tr>")
+		for _, cell := range row {
+			fmt.Fprintf(b, "<%s>%s</%s>", cellTag, html.EscapeString(cell), cellTag)
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</table>\n")
+}
+
+func getDriveService() *drive.Service {
+	driveService, err := drive.NewService(CTX, googleServiceOptions()...)
+	if err != nil {
+		logln("could not create Google Drive client")
+		panic(err)
+	}
+
+	return driveService
+}
+
+// exportFile has Drive convert a Google file to mimeType and hands back the
+// converted bytes. Export doesn't take supportsAllDrives like the other Drive
+// calls, it already works on files in shared drives. It's a variable so the
+// tests can stand in for Drive.
+var exportFile = func(fileId string, mimeType string) ([]byte, error) {
+	resp, err := getDriveService().Files.Export(fileId, mimeType).Context(CTX).Download()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+// exportDocumentText is the alternative to readTextFromDocument. Drive's own
+// export picks up tables, footnotes, and the like that our paragraph walk
+// skips over.
+func exportDocumentText(documentId string, mimeType string) string {
+	logf("Exporting the text from the document as %s\n", mimeType)
+	content, err := exportFile(documentId, mimeType)
+	if err != nil {
+		logln("Could not export the document")
+		panic(err)
+	}
+
+	return string(content)
+}
+
+// safeFileName keeps titles like "Q3/Q4 Planning" from turning into paths
+func safeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		return "presentation"
+	}
+
+	return name
+}
@@ -0,0 +1,83 @@
+package doctorslides
+
+import (
+	"google.golang.org/api/docs/v1"
+	"strings"
+	"testing"
+)
+
+func textParagraph(text string) *docs.StructuralElement {
+	return &docs.StructuralElement{
+		Paragraph: &docs.Paragraph{
+			Elements: []*docs.ParagraphElement{
+				{TextRun: &docs.TextRun{Content: text}},
+			},
+		},
+	}
+}
+
+func tableDocument(rows [][]string) *docs.Document {
+	table := &docs.Table{}
+	for _, row := range rows {
+		tableRow := &docs.TableRow{}
+		for _, cell := range row {
+			tableRow.TableCells = append(tableRow.TableCells, &docs.TableCell{
+				Content: []*docs.StructuralElement{textParagraph(cell + "\n")},
+			})
+		}
+		table.TableRows = append(table.TableRows, tableRow)
+	}
+
+	return &docs.Document{
+		Title: "Pricing",
+		Body: &docs.Body{
+			Content: []*docs.StructuralElement{
+				textParagraph("Our plans\n"),
+				{Table: table},
+				textParagraph("Pick one\n"),
+			},
+		},
+	}
+}
+
+// The structured reader keeps a table's rows and cells apart as table lines,
+// where Drive's plain text export only gets the words. Both have to end up
+// with everything that's in the doc.
+func TestTableDocumentReadModes(t *testing.T) {
+	useDefaultOptions(t)
+	rows := [][]string{{"Plan", "Price"}, {"Basic", "$5"}, {"Pro", "$20"}}
+	document := tableDocument(rows)
+
+	structured := readTextFromDocument(document)
+	for _, row := range rows {
+		line := OUTLINE_FORMAT.Table + strings.Join(row, " | ")
+		if !strings.Contains(structured, line) {
+			t.Errorf("structured text is missing the table line %q:\n%s", line, structured)
+		}
+	}
+
+	savedExport := exportFile
+	t.Cleanup(func() { exportFile = savedExport })
+	var gotId, gotMimeType string
+	exportFile = func(fileId string, mimeType string) ([]byte, error) {
+		gotId, gotMimeType = fileId, mimeType
+		return []byte("Our plans\nPlan\tPrice\nBasic\t$5\nPro\t$20\nPick one\n"), nil
+	}
+	exported := exportDocumentText("doc_id", "text/plain")
+	if gotId != "doc_id" || gotMimeType != "text/plain" {
+		t.Errorf("exported %s as %s, want doc_id as text/plain", gotId, gotMimeType)
+	}
+
+	for _, word := range []string{"Our plans", "Plan", "Price", "Basic", "$5", "Pro", "$20", "Pick one"} {
+		if !strings.Contains(structured, word) {
+			t.Errorf("structured text is missing %q", word)
+		}
+		if !strings.Contains(exported, word) {
+			t.Errorf("exported text is missing %q", word)
+		}
+	}
+	// Only the structured reader can tell GPT where the table is
+	if strings.Contains(exported, OUTLINE_FORMAT.Table) {
+		t.Errorf("exported text shouldn't have table lines:\n%s", exported)
+	}
+}
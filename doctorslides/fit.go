@@ -0,0 +1,44 @@
+package doctorslides
+
+import (
+	"fmt"
+	"strings"
+)
+
+// likelyOverflows guesses whether a slide has more text than will fit in the
+// body placeholder. It's a rough guess since the real answer depends on the
+// theme's fonts.
+func likelyOverflows(slide SimpleSlide, maxChars int, maxBullets int) bool {
+	chars := 0
+	for _, bullet := range slide.Bullets {
+		chars += len([]rune(bullet.Text))
+	}
+
+	return chars > maxChars || len(slide.Bullets) > maxBullets
+}
+
+// checkSlideFit warns about slides that probably run off the bottom, even
+// after --autofit shrink has done what it can. With --strict-fit that's an
+// error instead, and we stop before making anything.
+func checkSlideFit(outline GPTOutline) {
+	crowded := make([]string, 0)
+	for i, slide := range outline.Slides {
+		// Shrinking only helps down to a point
+		if options.Autofit == "shrink" && autofitFontSize(slide) > MIN_AUTOFIT_FONT_SIZE {
+			continue
+		}
+		if likelyOverflows(slide, options.FitMaxChars, options.FitMaxBullets) {
+			crowded = append(crowded, fmt.Sprintf("  %d. %s (%d bullets)", i+1, slide.Title, len(slide.Bullets)))
+		}
+	}
+	if len(crowded) == 0 {
+		return
+	}
+
+	logln("These slides probably have more text than will fit:")
+	logln(strings.Join(crowded, "\n"))
+	logln("Try --max-bullets or --truncate-bullets, or split the content up in the doc.")
+	if options.StrictFit {
+		fatalf("Not making the deck because of --strict-fit.")
+	}
+}
@@ -0,0 +1,153 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/drive/v3"
+	"strings"
+	"sync"
+)
+
+const GOOGLE_DOC_MIME_TYPE = "application/vnd.google-apps.document"
+
+// listFolderDocs finds every Google Doc sitting directly in a Drive folder
+func listFolderDocs(folderId string) []*drive.File {
+	query := fmt.Sprintf("'%s' in parents and mimeType = '%s' and trashed = false", folderId, GOOGLE_DOC_MIME_TYPE)
+	call := getDriveService().Files.List().Q(query).Fields("nextPageToken, files(id, name, modifiedTime)").
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true)
+	if options.SharedDrive != "" {
+		call = call.Corpora("drive").DriveId(options.SharedDrive)
+	}
+	files := make([]*drive.File, 0)
+	err := call.Pages(CTX, func(page *drive.FileList) error {
+		files = append(files, page.Files...)
+		return nil
+	})
+	if err != nil {
+		logln("Could not list the folder")
+		panic(err)
+	}
+
+	return files
+}
+
+// outputFolder is the Drive folder new decks should end up in. Without
+// --output-folder a deck made for a shared drive goes in the top of that
+// drive, since the drive's ID doubles as its root folder.
+func outputFolder() string {
+	if options.OutputFolder != "" {
+		return options.OutputFolder
+	}
+
+	return options.SharedDrive
+}
+
+// moveToFolder moves a file out of wherever Drive put it and into folderId.
+// Slides can only create decks in the user's own My Drive, so this is how
+// they get into other folders and shared drives.
+func moveToFolder(fileId string, folderId string) {
+	driveService := getDriveService()
+	file, err := driveService.Files.Get(fileId).Fields("parents").SupportsAllDrives(true).Context(CTX).Do()
+	if err != nil {
+		logln("Could not look up where the presentation was made")
+		panic(err)
+	}
+	_, err = driveService.Files.Update(fileId, &drive.File{}).
+		AddParents(folderId).
+		RemoveParents(strings.Join(file.Parents, ",")).
+		SupportsAllDrives(true).
+		Context(CTX).
+		Do()
+	if err != nil {
+		logln("Could not move the presentation into the output folder")
+		panic(err)
+	}
+	logf("Moved the presentation into folder %s\n", folderId)
+}
+
+// FolderResult is how generating a deck for one doc in the folder went
+type FolderResult struct {
+	Doc            *drive.File
+	PresentationId string
+	URL            string
+	Err            error
+	// Skipped is set for docs that haven't changed since their last deck
+	Skipped bool
+}
+
+// generateFromFolder makes a deck for every doc in the folder, running up to
+// concurrency of them at once. A doc that fails gets reported at the end
+// instead of stopping the others. Docs that haven't been modified since the
+// last run are skipped unless --force is on.
+func generateFromFolder(folderId string, concurrency int) {
+	docs := listFolderDocs(folderId)
+	if len(docs) == 0 {
+		fatalf("There aren't any Google Docs in that folder.")
+	}
+	logf("Found %d docs in the folder\n", len(docs))
+	state := readFolderState(options.StateFile)
+
+	// fatalf normally ends the whole program. Here it needs to only end the
+	// one doc, so it panics instead and generateOne picks it back up.
+	options.collectFailures = true
+	results := make([]FolderResult, len(docs))
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, doc := range docs {
+		wg.Add(1)
+		go func(i int, doc *drive.File) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			previous, seen := state[doc.Id]
+			if seen && previous.ModifiedTime == doc.ModifiedTime && !options.Force {
+				results[i] = FolderResult{Doc: doc, PresentationId: previous.PresentationId, URL: previous.URL, Skipped: true}
+				return
+			}
+			results[i] = generateOne(doc)
+		}(i, doc)
+	}
+	wg.Wait()
+	options.collectFailures = false
+
+	failures := 0
+	logln("Here's how the folder went:")
+	for _, result := range results {
+		if result.Err != nil {
+			failures++
+			logf("  %s: FAILED: %s\n", result.Doc.Name, result.Err)
+			continue
+		}
+		if result.Skipped {
+			logf("  %s: skipped, unchanged since %s: %s\n", result.Doc.Name, result.Doc.ModifiedTime, result.URL)
+			continue
+		}
+		logf("  %s: %s\n", result.Doc.Name, result.URL)
+		state[result.Doc.Id] = FolderStateEntry{
+			Name:           result.Doc.Name,
+			ModifiedTime:   result.Doc.ModifiedTime,
+			PresentationId: result.PresentationId,
+			URL:            result.URL,
+		}
+	}
+	writeFolderState(options.StateFile, state)
+	if failures > 0 {
+		fatalf("%d of %d docs failed", failures, len(docs))
+	}
+}
+
+func generateOne(doc *drive.File) (result FolderResult) {
+	result.Doc = doc
+	defer func() {
+		if r := recover(); r != nil {
+			result.Err = panicError(r)
+		}
+	}()
+	outline := buildOutlineFromDocument(doc.Id)
+	result.PresentationId, result.URL = writeToSlides(outline)
+
+	return result
+}
@@ -0,0 +1,50 @@
+package doctorslides
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+)
+
+// FolderState is what we remember about each doc from the last --from-folder
+// run, keyed by doc ID, so docs that haven't changed can be skipped
+type FolderState map[string]FolderStateEntry
+
+type FolderStateEntry struct {
+	Name           string `json:"name"`
+	ModifiedTime   string `json:"modifiedTime"`
+	PresentationId string `json:"presentationId"`
+	URL            string `json:"url"`
+}
+
+// readFolderState loads the state file. Not having one yet is fine, that just
+// means nothing has been made before.
+func readFolderState(path string) FolderState {
+	state := FolderState{}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state
+	}
+	if err != nil {
+		logln("Could not read the folder state file")
+		panic(err)
+	}
+	err = json.Unmarshal(content, &state)
+	if err != nil {
+		fatalf("The folder state file %s isn't valid JSON: %s", path, err)
+	}
+
+	return state
+}
+
+func writeFolderState(path string, state FolderState) {
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	err = os.WriteFile(path, content, 0644)
+	if err != nil {
+		logln("Could not save the folder state file")
+		panic(err)
+	}
+}
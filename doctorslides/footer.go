@@ -0,0 +1,126 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/slides/v1"
+	"strings"
+	"time"
+)
+
+const (
+	FOOTER_MARGIN = 311700
+	FOOTER_WIDTH  = 4000000
+	FOOTER_HEIGHT = 250000
+
+	PAGE_NUMBER_WIDTH = 1000000
+)
+
+// footerText puts together the footer line for the content slides. It's
+// empty when neither --footer nor --author was given.
+func footerText(now time.Time) string {
+	if options.Footer == "" && options.Author == "" {
+		return ""
+	}
+	parts := make([]string, 0)
+	if options.Footer != "" {
+		parts = append(parts, options.Footer)
+	}
+	if options.Author != "" {
+		parts = append(parts, options.Author)
+	}
+	if options.FooterDate {
+		parts = append(parts, now.Format("January 2, 2006"))
+	}
+
+	return strings.Join(parts, " · ")
+}
+
+// buildFooterRequests puts the footer on a content slide. The layout's own
+// footer placeholder gets used when it has one, otherwise we make a small
+// text box along the bottom of the slide.
+func buildFooterRequests(slide *slides.Page, index int, text string) []*slides.Request {
+	return buildCornerTextRequests(slide, fmt.Sprintf("footer_%d", index), "FOOTER", text, options.FooterPosition, FOOTER_WIDTH)
+}
+
+// buildPageNumberRequests numbers a slide like "3 / 12". It goes in the
+// bottom corner the footer isn't using, in the same small gray text.
+func buildPageNumberRequests(slide *slides.Page, index int, total int) []*slides.Request {
+	position := "right"
+	if options.FooterPosition == "right" {
+		position = "left"
+	}
+	text := fmt.Sprintf("%d / %d", index, total)
+
+	return buildCornerTextRequests(slide, fmt.Sprintf("page_number_%d", index), "SLIDE_NUMBER", text, position, PAGE_NUMBER_WIDTH)
+}
+
+// buildCornerTextRequests writes small print in a bottom corner of the slide,
+// in the layout's placeholder of placeholderType if there is one or a new
+// text box of boxId if not
+func buildCornerTextRequests(slide *slides.Page, boxId string, placeholderType string, text string, position string, width float64) []*slides.Request {
+	requests := make([]*slides.Request, 0)
+	if placeholder := findPlaceholder(slide, placeholderType); placeholder != nil {
+		boxId = placeholder.ObjectId
+		requests = append(requests, buildClearTextRequests(placeholder)...)
+	} else {
+		pageWidth, pageHeight := pageSize()
+		x := float64(FOOTER_MARGIN)
+		if position == "right" {
+			x = pageWidth - FOOTER_MARGIN - width
+		}
+		requests = append(requests, &slides.Request{
+			CreateShape: &slides.CreateShapeRequest{
+				ObjectId:  boxId,
+				ShapeType: "TEXT_BOX",
+				ElementProperties: elementProperties(slide.ObjectId, ImagePlacement{
+					X:      x,
+					Y:      pageHeight - FOOTER_HEIGHT - FOOTER_MARGIN/2,
+					Width:  width,
+					Height: FOOTER_HEIGHT,
+				}),
+			},
+		})
+	}
+
+	alignment := "START"
+	if position == "right" {
+		alignment = "END"
+	}
+	requests = append(requests,
+		&slides.Request{
+			InsertText: &slides.InsertTextRequest{
+				ObjectId: boxId,
+				Text:     text,
+			},
+		},
+		&slides.Request{
+			UpdateTextStyle: &slides.UpdateTextStyleRequest{
+				ObjectId: boxId,
+				Style: &slides.TextStyle{
+					FontSize:        &slides.Dimension{Magnitude: 9, Unit: "PT"},
+					ForegroundColor: mutedColor(),
+				},
+				Fields: "fontSize,foregroundColor",
+			},
+		},
+		&slides.Request{
+			UpdateParagraphStyle: &slides.UpdateParagraphStyleRequest{
+				ObjectId: boxId,
+				Style: &slides.ParagraphStyle{
+					Alignment: alignment,
+				},
+				Fields: "alignment",
+			},
+		},
+	)
+
+	return requests
+}
+
+func mutedColor() *slides.OptionalColor {
+	return &slides.OptionalColor{
+		OpaqueColor: &slides.OpaqueColor{
+			RgbColor: &slides.RgbColor{Red: 0.5, Green: 0.5, Blue: 0.5},
+		},
+	}
+}
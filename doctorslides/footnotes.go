@@ -0,0 +1,111 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/docs/v1"
+	"strings"
+)
+
+// FOOTNOTES_TITLE is the slide the doc's footnotes get listed on at the end
+// with --include-footnotes
+const FOOTNOTES_TITLE = "References"
+
+// FOOTNOTES_HEADING starts the list of footnotes after the doc's text, so GPT
+// can see what each [1] is about
+const FOOTNOTES_HEADING = "Footnotes:"
+
+func footnoteMarker(reference *docs.FootnoteReference) string {
+	return fmt.Sprintf("[%s]", reference.FootnoteNumber)
+}
+
+// footnoteText is everything in the footnote squashed onto one line
+func footnoteText(document *docs.Document, footnoteId string) string {
+	footnote, ok := document.Footnotes[footnoteId]
+	if !ok {
+		return ""
+	}
+	parts := make([]string, 0)
+	for _, bodyElement := range footnote.Content {
+		if bodyElement.Paragraph == nil {
+			continue
+		}
+		if text := strings.TrimSpace(paragraphText(bodyElement.Paragraph)); text != "" {
+			parts = append(parts, text)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// paragraphFootnotes are the footnotes the paragraph points at, in order,
+// each with its marker in front
+func paragraphFootnotes(document *docs.Document, paragraph *docs.Paragraph) []string {
+	footnotes := make([]string, 0)
+	for _, paragraphElement := range paragraph.Elements {
+		reference := paragraphElement.FootnoteReference
+		if reference == nil {
+			continue
+		}
+		if text := footnoteText(document, reference.FootnoteId); text != "" {
+			footnotes = append(footnotes, footnoteMarker(reference)+" "+text)
+		}
+	}
+
+	return footnotes
+}
+
+// documentFootnotes are all of the doc's footnotes in the order they come up
+// in the text
+func documentFootnotes(document *docs.Document) []string {
+	footnotes := make([]string, 0)
+	if len(document.Footnotes) == 0 {
+		return footnotes
+	}
+	for _, bodyElement := range document.Body.Content {
+		if bodyElement.Paragraph != nil {
+			footnotes = append(footnotes, paragraphFootnotes(document, bodyElement.Paragraph)...)
+		}
+	}
+
+	return footnotes
+}
+
+// footnotesText lists the footnotes after the doc's text for GPT
+func footnotesText(document *docs.Document) string {
+	footnotes := documentFootnotes(document)
+	if len(footnotes) == 0 {
+		return ""
+	}
+
+	return "\n" + FOOTNOTES_HEADING + "\n" + strings.Join(footnotes, "\n") + "\n"
+}
+
+func footnoteInstruction() string {
+	if !options.IncludeFootnotes {
+		return ""
+	}
+
+	return fmt.Sprintf(" The document's footnotes are marked like [1] in the text and listed after \"%s\" at the end. When one matters to a slide, put it in that slide's speaker notes. Don't make slides out of the footnotes themselves.", FOOTNOTES_HEADING)
+}
+
+// addFootnotesSlide puts every footnote on a slide at the end, so the
+// sources are in the deck even when GPT leaves them out of the notes
+func addFootnotesSlide(outline GPTOutline, document *docs.Document) GPTOutline {
+	if !options.IncludeFootnotes {
+		return outline
+	}
+	footnotes := documentFootnotes(document)
+	if len(footnotes) == 0 {
+		if DEBUG {
+			logln("--include-footnotes didn't find any footnotes in the document")
+		}
+		return outline
+	}
+	slide := SimpleSlide{Title: FOOTNOTES_TITLE, Bullets: make([]Bullet, 0)}
+	for _, footnote := range footnotes {
+		slide.Bullets = append(slide.Bullets, Bullet{Text: footnote})
+	}
+	outline.Slides = append(outline.Slides, slide)
+
+	return outline
+}
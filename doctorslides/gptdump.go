@@ -0,0 +1,66 @@
+package doctorslides
+
+import (
+	"fmt"
+	"github.com/sashabaranov/go-openai"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// dumpStarted is whether this run has written to --dump-gpt yet. The
+	// first write replaces whatever an earlier run left there and the rest
+	// (retries, other docs in a folder) add on to the end.
+	dumpStarted bool
+	dumpMutex   sync.Mutex
+)
+
+func orDefault(value string, isDefault bool) string {
+	if isDefault {
+		return "default"
+	}
+
+	return value
+}
+
+// dumpGPT saves the prompt and whatever GPT said back to --dump-gpt, before
+// we've tried to parse any of it
+func dumpGPT(path string, req openai.ChatCompletionRequest, resp openai.ChatCompletionResponse) {
+	dumpMutex.Lock()
+	defer dumpMutex.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "=== %s ===\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Model: %s\n", req.Model)
+	// Zero for these means we left them to OpenAI's defaults
+	fmt.Fprintf(&b, "Temperature: %s\n", orDefault(fmt.Sprint(req.Temperature), req.Temperature == 0))
+	fmt.Fprintf(&b, "Max tokens: %s\n", orDefault(fmt.Sprint(req.MaxTokens), req.MaxTokens == 0))
+	fmt.Fprintf(&b, "Choices: %d\n", len(resp.Choices))
+	fmt.Fprintf(&b, "Tokens used: %d prompt, %d completion\n", resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+	for _, message := range req.Messages {
+		fmt.Fprintf(&b, "\n--- Prompt (%s) ---\n%s\n", message.Role, message.Content)
+	}
+	for i, choice := range resp.Choices {
+		fmt.Fprintf(&b, "\n--- Response %d (finish reason: %s) ---\n%s\n", i+1, choice.FinishReason, choice.Message.Content)
+	}
+	b.WriteString("\n")
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if !dumpStarted {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		logf("Could not open the GPT dump file: %s\n", err)
+		return
+	}
+	defer f.Close()
+	_, err = f.WriteString(b.String())
+	if err != nil {
+		logf("Could not write the GPT dump file: %s\n", err)
+		return
+	}
+	dumpStarted = true
+}
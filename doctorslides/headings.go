@@ -0,0 +1,97 @@
+package doctorslides
+
+import (
+	"google.golang.org/api/docs/v1"
+	"strings"
+)
+
+// topHeadingLevel is the biggest heading the doc uses, not counting its
+// TITLE, which is what each slide gets made from in --no-ai mode
+func topHeadingLevel(document *docs.Document) (int, bool) {
+	top := 0
+	for _, bodyElement := range document.Body.Content {
+		if bodyElement.Paragraph == nil {
+			continue
+		}
+		level, isHeading := headingLevel(bodyElement.Paragraph)
+		if isHeading && level > 0 && (top == 0 || level < top) {
+			top = level
+		}
+	}
+
+	return top, top > 0
+}
+
+// outlineFromHeadings turns the doc into an outline one for one, without
+// asking GPT anything. Each top level heading is a slide, and the
+// paragraphs, list items, and smaller headings under it are its bullets,
+// nested the way the doc's lists are. Anything before the first heading
+// goes on a slide of its own so it isn't lost.
+func outlineFromHeadings(document *docs.Document) GPTOutline {
+	logln("Building the outline from the document's headings")
+	outline := GPTOutline{Slides: make([]SimpleSlide, 0)}
+	top, found := topHeadingLevel(document)
+	if !found {
+		fatalf("--no-ai needs a document with headings to make the slides from, and this one doesn't have any.")
+	}
+	patterns := boilerplatePatterns(options.StripPatterns)
+	var current *SimpleSlide
+	startSlide := func(title string) {
+		// Every slide starts at a top heading, so each one is its own section
+		outline.Slides = append(outline.Slides, SimpleSlide{
+			Title:   title,
+			Bullets: make([]Bullet, 0),
+			Section: title,
+		})
+		current = &outline.Slides[len(outline.Slides)-1]
+	}
+	for _, bodyElement := range document.Body.Content {
+		if bodyElement.Table != nil {
+			if current == nil {
+				startSlide(UNNAMED_TITLE)
+			}
+			// A slide only gets the one table, so any more become bullets
+			if current.Table == nil {
+				current.Table = normalizeTable(tableCells(bodyElement.Table))
+			} else {
+				for _, row := range tableCells(bodyElement.Table) {
+					current.Bullets = append(current.Bullets, Bullet{Text: strings.Join(row, " | ")})
+				}
+			}
+			continue
+		}
+		paragraph := bodyElement.Paragraph
+		if paragraph == nil {
+			continue
+		}
+		text := strings.TrimSpace(paragraphText(paragraph))
+		if text == "" || (!options.NoStrip && isBoilerplate(text, patterns)) {
+			continue
+		}
+		level, isHeading := headingLevel(paragraph)
+		if isHeading && level == 0 {
+			// That's the doc's TITLE, and the deck is named after the doc
+			continue
+		}
+		if isHeading && level == top {
+			startSlide(text)
+			continue
+		}
+		if current == nil {
+			startSlide(UNNAMED_TITLE)
+		}
+		if options.IncludeFootnotes {
+			for _, footnote := range paragraphFootnotes(document, paragraph) {
+				current.Notes = strings.TrimSpace(current.Notes + "\n" + footnote)
+			}
+		}
+		bullet := Bullet{Text: text}
+		if paragraph.Bullet != nil {
+			bullet.Level = int(paragraph.Bullet.NestingLevel)
+		}
+		current.Bullets = append(current.Bullets, bullet)
+	}
+	logf("Made %d slides from the headings\n", len(outline.Slides))
+
+	return outline
+}
@@ -0,0 +1,50 @@
+package doctorslides
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+)
+
+// checkOutlineImages probes the image URL on every slide and prints how each
+// one did. GPT loves to make up image URLs, so this is a cheap way to find
+// out before building anything. The bool is false if any of them are dead.
+func checkOutlineImages(outline GPTOutline) bool {
+	logln("Checking the image URLs")
+	errs := runBounded(CTX, len(outline.Slides), options.ImageConcurrency, options.ImageTimeout, func(ctx context.Context, i int) error {
+		if outline.Slides[i].Image == "" {
+			return nil
+		}
+		// No point fetching an image that's going to get dropped anyway
+		if !isAllowedImageHost(outline.Slides[i].Image) {
+			return errImageNotAllowed
+		}
+		return validateImageURL(ctx, outline.Slides[i].Image)
+	})
+
+	allAlive := true
+	table := tabwriter.NewWriter(LOG, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "SLIDE\tURL\tSTATUS")
+	for i, slide := range outline.Slides {
+		status := "OK"
+		if slide.Image == "" {
+			status = "no image"
+		} else if errs[i] == errImageNotAllowed {
+			status = "not allowed"
+		} else if errors.Is(errs[i], ErrNotAnImage) {
+			status = "non-image"
+			allAlive = false
+		} else if errs[i] != nil {
+			status = "dead"
+			allAlive = false
+		}
+		if errs[i] != nil && DEBUG {
+			status = fmt.Sprintf("%s (%s)", status, errs[i])
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\n", slide.Title, slide.Image, status)
+	}
+	table.Flush()
+
+	return allAlive
+}
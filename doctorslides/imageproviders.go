@@ -0,0 +1,211 @@
+package doctorslides
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/api/docs/v1"
+	"strings"
+)
+
+// ImageProvider is one place slide images can come from. A blank URL with no
+// error means it just didn't have anything for that slide.
+type ImageProvider interface {
+	Name() string
+	FindImage(ctx context.Context, slide SimpleSlide) (SlideImage, error)
+}
+
+// outlineImageProvider uses the image URL that's already in the outline,
+// which GPT suggests or a Markdown file brings along
+type outlineImageProvider struct{}
+
+func (outlineImageProvider) Name() string { return "gpt" }
+
+func (outlineImageProvider) FindImage(ctx context.Context, slide SimpleSlide) (SlideImage, error) {
+	return SlideImage{URL: slide.Image}, nil
+}
+
+type unsplashImageProvider struct{}
+
+func (unsplashImageProvider) Name() string { return "unsplash" }
+
+func (unsplashImageProvider) FindImage(ctx context.Context, slide SimpleSlide) (SlideImage, error) {
+	return searchUnsplash(ctx, slide.Title)
+}
+
+type pexelsImageProvider struct{}
+
+func (pexelsImageProvider) Name() string { return "pexels" }
+
+func (pexelsImageProvider) FindImage(ctx context.Context, slide SimpleSlide) (SlideImage, error) {
+	return searchPexels(ctx, slide.Title)
+}
+
+type dalleImageProvider struct{}
+
+func (dalleImageProvider) Name() string { return "dalle" }
+
+func (dalleImageProvider) FindImage(ctx context.Context, slide SimpleSlide) (SlideImage, error) {
+	return generateDalleImage(ctx, slide.Title)
+}
+
+// docImageProvider uses the picture from the doc that attachDocImages
+// matched up with the slide
+type docImageProvider struct{}
+
+func (docImageProvider) Name() string { return "doc" }
+
+func (docImageProvider) FindImage(ctx context.Context, slide SimpleSlide) (SlideImage, error) {
+	return SlideImage{URL: slide.DocImage}, nil
+}
+
+// IMAGE_PROVIDERS are the --image-source names
+var IMAGE_PROVIDERS = map[string]ImageProvider{
+	"gpt":      outlineImageProvider{},
+	"unsplash": unsplashImageProvider{},
+	"pexels":   pexelsImageProvider{},
+	"dalle":    dalleImageProvider{},
+	"doc":      docImageProvider{},
+}
+
+// parseImageSources reads an --image-source list like "doc,unsplash,dalle".
+// "none" only makes sense on its own, and turns images off.
+func parseImageSources(value string) ([]string, error) {
+	sources := make([]string, 0)
+	for _, source := range strings.Split(value, ",") {
+		source = strings.ToLower(strings.TrimSpace(source))
+		if !isOneOf(source, IMAGE_SOURCES) {
+			return nil, fmt.Errorf("I don't know the image source \"%s\". Try one of: %s", source, strings.Join(IMAGE_SOURCES, ", "))
+		}
+		if isOneOf(source, sources) {
+			return nil, fmt.Errorf("--image-source has %s in it twice", source)
+		}
+		sources = append(sources, source)
+	}
+	if isOneOf("none", sources) {
+		if len(sources) > 1 {
+			return nil, fmt.Errorf("--image-source none can't be mixed with other sources")
+		}
+		return nil, nil
+	}
+
+	return sources, nil
+}
+
+func imageProviders(sources []string) []ImageProvider {
+	providers := make([]ImageProvider, 0, len(sources))
+	for _, source := range sources {
+		providers = append(providers, IMAGE_PROVIDERS[source])
+	}
+
+	return providers
+}
+
+// findChainedImage asks each provider in turn and goes with the first image
+// that's allowed, actually loads, and can be measured when the fit needs
+// it. When none of them work out the last thing that went wrong is what
+// gets reported.
+func findChainedImage(ctx context.Context, slide SimpleSlide, providers []ImageProvider) (SlideImage, error) {
+	var lastErr error
+	for _, provider := range providers {
+		image, err := provider.FindImage(ctx, slide)
+		// Images from other domains are dropped before anything else
+		// happens to them
+		if err == nil && allowedImage(image.URL) == "" {
+			image = SlideImage{}
+		}
+		if err == nil && image.URL != "" {
+			err = validateImageURL(ctx, image.URL)
+		}
+		// Guessing at the size is how images end up squashed, so ones we
+		// can't measure get skipped
+		if err == nil && image.URL != "" && options.ImageFit != "stretch" {
+			image.Width, image.Height, err = fetchImageSize(ctx, image.URL)
+		}
+		if err != nil {
+			if DEBUG {
+				logf("The %s image for \"%s\" didn't work out: %s\n", provider.Name(), slide.Title, err)
+			}
+			lastErr = fmt.Errorf("%s: %w", provider.Name(), err)
+			continue
+		}
+		if image.URL != "" {
+			return image, nil
+		}
+	}
+
+	return SlideImage{}, lastErr
+}
+
+// DocImage is a picture from the doc along with the heading it sits under
+type DocImage struct {
+	URL     string
+	Heading string
+}
+
+// documentImages lists the doc's inline pictures in the order they come.
+// The URLs Docs hands out only last about half an hour, which is plenty to
+// get them into the deck.
+func documentImages(document *docs.Document) []DocImage {
+	images := make([]DocImage, 0)
+	heading := ""
+	for _, bodyElement := range document.Body.Content {
+		paragraph := bodyElement.Paragraph
+		if paragraph == nil {
+			continue
+		}
+		if _, isHeading := headingLevel(paragraph); isHeading {
+			heading = strings.TrimSpace(paragraphText(paragraph))
+		}
+		for _, paragraphElement := range paragraph.Elements {
+			if paragraphElement.InlineObjectElement == nil {
+				continue
+			}
+			object, ok := document.InlineObjects[paragraphElement.InlineObjectElement.InlineObjectId]
+			if !ok || object.InlineObjectProperties == nil || object.InlineObjectProperties.EmbeddedObject == nil {
+				continue
+			}
+			properties := object.InlineObjectProperties.EmbeddedObject.ImageProperties
+			if properties == nil || properties.ContentUri == "" {
+				continue
+			}
+			images = append(images, DocImage{URL: properties.ContentUri, Heading: heading})
+		}
+	}
+
+	return images
+}
+
+// attachDocImages gives each slide the first unused picture from under a
+// heading matching its title, or failing that its section. Slides without
+// a match are left for the next --image-source to fill.
+func attachDocImages(outline GPTOutline, document *docs.Document) GPTOutline {
+	if !isOneOf("doc", options.imageSources) {
+		return outline
+	}
+	images := documentImages(document)
+	used := make([]bool, len(images))
+	matchImage := func(heading string) string {
+		if heading == "" {
+			return ""
+		}
+		for i, image := range images {
+			if !used[i] && strings.EqualFold(image.Heading, heading) {
+				used[i] = true
+				return image.URL
+			}
+		}
+		return ""
+	}
+	for i := range outline.Slides {
+		slide := &outline.Slides[i]
+		slide.DocImage = matchImage(slide.Title)
+		if slide.DocImage == "" {
+			slide.DocImage = matchImage(slide.Section)
+		}
+	}
+	if DEBUG {
+		logf("Found %d pictures in the doc to use on slides\n", len(images))
+	}
+
+	return outline
+}
@@ -0,0 +1,142 @@
+package doctorslides
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// stubImageProvider hands back the same image or error for every slide and
+// notes down that it was asked
+type stubImageProvider struct {
+	name  string
+	url   string
+	err   error
+	asked *[]string
+}
+
+func (p stubImageProvider) Name() string { return p.name }
+
+func (p stubImageProvider) FindImage(ctx context.Context, slide SimpleSlide) (SlideImage, error) {
+	*p.asked = append(*p.asked, p.name)
+
+	return SlideImage{URL: p.url}, p.err
+}
+
+// imageServer serves a 4x3 PNG at every path but /missing
+func imageServer(t *testing.T) *httptest.Server {
+	var picture bytes.Buffer
+	if err := png.Encode(&picture, image.NewRGBA(image.Rect(0, 0, 4, 3))); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(picture.Bytes())
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestFindChainedImage(t *testing.T) {
+	useDefaultOptions(t)
+	server := imageServer(t)
+	errSearch := errors.New("search is down")
+
+	tests := []struct {
+		name      string
+		allowlist []string
+		providers func(asked *[]string) []ImageProvider
+		wantURL   string
+		wantAsked []string
+		wantErr   error
+	}{
+		{
+			name: "goes down the list until one has an image",
+			providers: func(asked *[]string) []ImageProvider {
+				return []ImageProvider{
+					stubImageProvider{name: "first", asked: asked},
+					stubImageProvider{name: "second", url: server.URL + "/second.png", asked: asked},
+					stubImageProvider{name: "third", url: server.URL + "/third.png", asked: asked},
+				}
+			},
+			wantURL:   server.URL + "/second.png",
+			wantAsked: []string{"first", "second"},
+		},
+		{
+			name: "an error falls through to the next provider",
+			providers: func(asked *[]string) []ImageProvider {
+				return []ImageProvider{
+					stubImageProvider{name: "first", err: errSearch, asked: asked},
+					stubImageProvider{name: "second", url: server.URL + "/missing", asked: asked},
+					stubImageProvider{name: "third", url: server.URL + "/third.png", asked: asked},
+				}
+			},
+			wantURL:   server.URL + "/third.png",
+			wantAsked: []string{"first", "second", "third"},
+		},
+		{
+			name:      "images off the allowlist are skipped",
+			allowlist: []string{"127.0.0.1"},
+			providers: func(asked *[]string) []ImageProvider {
+				return []ImageProvider{
+					// The same server, but by a name that isn't on the list
+					stubImageProvider{name: "first", url: strings.Replace(server.URL, "127.0.0.1", "localhost", 1) + "/first.png", asked: asked},
+					stubImageProvider{name: "second", url: server.URL + "/second.png", asked: asked},
+				}
+			},
+			wantURL:   server.URL + "/second.png",
+			wantAsked: []string{"first", "second"},
+		},
+		{
+			name: "the last error comes back when nothing works",
+			providers: func(asked *[]string) []ImageProvider {
+				return []ImageProvider{
+					stubImageProvider{name: "first", url: server.URL + "/missing", asked: asked},
+					stubImageProvider{name: "second", err: errSearch, asked: asked},
+				}
+			},
+			wantAsked: []string{"first", "second"},
+			wantErr:   errSearch,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options.imageAllowlist = test.allowlist
+			asked := make([]string, 0)
+			image, err := findChainedImage(context.Background(), SimpleSlide{Title: "Cats"}, test.providers(&asked))
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("got error %v, want %v", err, test.wantErr)
+				}
+			} else if err != nil {
+				t.Errorf("got error %v", err)
+			}
+			if image.URL != test.wantURL {
+				t.Errorf("got %q, want %q", image.URL, test.wantURL)
+			}
+			if test.wantURL != "" && (image.Width != 4 || image.Height != 3) {
+				t.Errorf("got a %dx%d image, want 4x3", image.Width, image.Height)
+			}
+			if len(asked) != len(test.wantAsked) {
+				t.Fatalf("asked %v, want %v", asked, test.wantAsked)
+			}
+			for i := range asked {
+				if asked[i] != test.wantAsked[i] {
+					t.Errorf("asked %v, want %v", asked, test.wantAsked)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,444 @@
+package doctorslides
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sashabaranov/go-openai"
+	"google.golang.org/api/slides/v1"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The default page size of a new presentation, in EMU
+const (
+	PAGE_WIDTH  = 9144000
+	PAGE_HEIGHT = 5143500
+)
+
+// Where images get placed on a content slide, in EMU. This sits in the bottom
+// right corner of the default page where the body text usually runs out, and
+// imageBox moves it to match other page sizes.
+const (
+	IMAGE_BOX_X      = 6096000
+	IMAGE_BOX_Y      = 1828800
+	IMAGE_BOX_WIDTH  = 2743200
+	IMAGE_BOX_HEIGHT = 2743200
+)
+
+var IMAGE_SOURCES = []string{"unsplash", "pexels", "gpt", "dalle", "doc", "none"}
+var IMAGE_FITS = []string{"contain", "cover", "stretch"}
+
+// ErrNotAnImage is for URLs that answer but with something other than a
+// picture, like the HTML "not found" pages GPT's made-up URLs usually hit
+var ErrNotAnImage = errors.New("not an image")
+
+type SlideImage struct {
+	URL string
+	// Credit is the attribution the provider wants shown next to the photo.
+	// It's empty for sources that don't ask for one.
+	Credit string
+	// Width and Height are in pixels, and only get looked up when the image
+	// fit needs them
+	Width  int
+	Height int
+}
+
+// ImageLookup is how finding an image went for one slide
+type ImageLookup struct {
+	Image SlideImage
+	Err   error
+}
+
+// findSlideImages looks up and checks the images for every slide at once,
+// with at most concurrency lookups running at a time. The results line up
+// with the slides they're for, and one slide failing doesn't stop the rest.
+func findSlideImages(ctx context.Context, slideOutlines []SimpleSlide, providers []ImageProvider, concurrency int, timeout time.Duration) []ImageLookup {
+	results := make([]ImageLookup, len(slideOutlines))
+	errs := runBounded(ctx, len(slideOutlines), concurrency, timeout, func(ctx context.Context, i int) error {
+		image, err := findChainedImage(ctx, slideOutlines[i], providers)
+		results[i].Image = image
+		return err
+	})
+	for i, err := range errs {
+		results[i].Err = err
+	}
+
+	return results
+}
+
+// runBounded calls work for 0 through count-1 with at most concurrency of
+// them going at once, each under its own timeout. The errors come back in
+// the same order.
+func runBounded(ctx context.Context, count int, concurrency int, timeout time.Duration, work func(context.Context, int) error) []error {
+	errs := make([]error, count)
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-semaphore }()
+
+			workCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			errs[i] = work(workCtx, i)
+		}(i)
+	}
+	wg.Wait()
+
+	return errs
+}
+
+// validateImageURL makes sure a URL actually serves an image before we hand
+// it to Slides, since one bad image URL fails the whole batch update
+func validateImageURL(ctx context.Context, imageURL string) error {
+	resp, err := requestImage(ctx, http.MethodHead, imageURL)
+	// Plenty of servers don't bother with HEAD, so give GET a shot too
+	if err != nil || resp.StatusCode == http.StatusMethodNotAllowed {
+		resp, err = requestImage(ctx, http.MethodGet, imageURL)
+	}
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned %s", resp.Request.URL.Host, resp.Status)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return fmt.Errorf("%w: got %s", ErrNotAnImage, contentType)
+	}
+
+	return nil
+}
+
+func requestImage(ctx context.Context, method string, imageURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, imageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	// Only the headers matter here
+	resp.Body.Close()
+
+	return resp, nil
+}
+
+func searchUnsplash(ctx context.Context, query string) (SlideImage, error) {
+	if UNSPLASH_ACCESS_KEY == "" {
+		return SlideImage{}, fmt.Errorf("UNSPLASH_ACCESS_KEY is not set")
+	}
+	endpoint := "https://api.unsplash.com/search/photos?per_page=1&query=" + url.QueryEscape(query)
+	var body struct {
+		Results []struct {
+			URLs struct {
+				Regular string `json:"regular"`
+			} `json:"urls"`
+			User struct {
+				Name string `json:"name"`
+			} `json:"user"`
+		} `json:"results"`
+	}
+	err := getJSON(ctx, endpoint, "Client-ID "+UNSPLASH_ACCESS_KEY, &body)
+	if err != nil || len(body.Results) == 0 {
+		return SlideImage{}, err
+	}
+	result := body.Results[0]
+
+	return SlideImage{
+		URL:    result.URLs.Regular,
+		Credit: fmt.Sprintf("Photo by %s on Unsplash", result.User.Name),
+	}, nil
+}
+
+func searchPexels(ctx context.Context, query string) (SlideImage, error) {
+	if PEXELS_API_KEY == "" {
+		return SlideImage{}, fmt.Errorf("PEXELS_API_KEY is not set")
+	}
+	endpoint := "https://api.pexels.com/v1/search?per_page=1&query=" + url.QueryEscape(query)
+	var body struct {
+		Photos []struct {
+			Photographer string `json:"photographer"`
+			Src          struct {
+				Large string `json:"large"`
+			} `json:"src"`
+		} `json:"photos"`
+	}
+	err := getJSON(ctx, endpoint, PEXELS_API_KEY, &body)
+	if err != nil || len(body.Photos) == 0 {
+		return SlideImage{}, err
+	}
+	photo := body.Photos[0]
+
+	return SlideImage{
+		URL:    photo.Src.Large,
+		Credit: fmt.Sprintf("Photo by %s on Pexels", photo.Photographer),
+	}, nil
+}
+
+func generateDalleImage(ctx context.Context, title string) (SlideImage, error) {
+	client := newOpenAIClient()
+	resp, err := client.CreateImage(ctx, openai.ImageRequest{
+		Prompt:         fmt.Sprintf("An illustration for a presentation slide titled \"%s\"", title),
+		N:              1,
+		Size:           openai.CreateImageSize512x512,
+		ResponseFormat: openai.CreateImageResponseFormatURL,
+	})
+	if err != nil || len(resp.Data) == 0 {
+		return SlideImage{}, err
+	}
+
+	return SlideImage{URL: resp.Data[0].URL}, nil
+}
+
+func getJSON(ctx context.Context, endpoint string, authorization string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	resp, err := httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", req.URL.Host, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// fetchImageSize reads just enough of an image to get its dimensions
+func fetchImageSize(ctx context.Context, imageURL string) (int, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	resp, err := httpClient().Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	config, _, err := image.DecodeConfig(resp.Body)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not work out the image size: %w", err)
+	}
+
+	return config.Width, config.Height, nil
+}
+
+func emu(magnitude float64) *slides.Dimension {
+	return &slides.Dimension{Magnitude: magnitude, Unit: "EMU"}
+}
+
+// ImagePlacement is where an image ends up on the slide, in EMU
+type ImagePlacement struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+}
+
+// placeImage fits an image into the image box. contain shrinks it until the
+// whole thing fits, cover grows it until the box is filled (spilling over the
+// edges), and stretch squashes it into the box no matter what. Either way it's
+// centered on the box.
+func placeImage(image SlideImage, fit string) ImagePlacement {
+	box := imageBox()
+	if fit == "stretch" || image.Width <= 0 || image.Height <= 0 {
+		return box
+	}
+
+	widthScale := box.Width / float64(image.Width)
+	heightScale := box.Height / float64(image.Height)
+	scale := math.Min(widthScale, heightScale)
+	if fit == "cover" {
+		scale = math.Max(widthScale, heightScale)
+	}
+	width := float64(image.Width) * scale
+	height := float64(image.Height) * scale
+
+	return ImagePlacement{
+		X:      box.X + (box.Width-width)/2,
+		Y:      box.Y + (box.Height-height)/2,
+		Width:  width,
+		Height: height,
+	}
+}
+
+func elementProperties(pageId string, placement ImagePlacement) *slides.PageElementProperties {
+	return &slides.PageElementProperties{
+		PageObjectId: pageId,
+		Size: &slides.Size{
+			Width:  emu(placement.Width),
+			Height: emu(placement.Height),
+		},
+		Transform: &slides.AffineTransform{
+			ScaleX:     1,
+			ScaleY:     1,
+			TranslateX: placement.X,
+			TranslateY: placement.Y,
+			Unit:       "EMU",
+		},
+	}
+}
+
+// The height of each line of small print under an image, in EMU
+const IMAGE_TEXT_LINE_HEIGHT = 228600
+
+// EMU_PER_PT is for sizes Slides hands back in points instead of EMU
+const EMU_PER_PT = 12700
+
+// picturePlaceholder is the layout's spot for a picture, if it has one. An
+// empty one can show up as a shape or as an image depending on the template.
+func picturePlaceholder(slide *slides.Page) *slides.PageElement {
+	for _, element := range slide.PageElements {
+		if element.Shape != nil && element.Shape.Placeholder != nil && element.Shape.Placeholder.Type == "PICTURE" {
+			return element
+		}
+		if element.Image != nil && element.Image.Placeholder != nil && element.Image.Placeholder.Type == "PICTURE" {
+			return element
+		}
+	}
+
+	return nil
+}
+
+func toEMU(magnitude float64, unit string) float64 {
+	if unit == "PT" {
+		return magnitude * EMU_PER_PT
+	}
+
+	return magnitude
+}
+
+// elementPlacement is where an element sits on the page, for lining things
+// up underneath it. The bool is false when Slides didn't say.
+func elementPlacement(element *slides.PageElement) (ImagePlacement, bool) {
+	if element.Size == nil || element.Size.Width == nil || element.Size.Height == nil || element.Transform == nil {
+		return ImagePlacement{}, false
+	}
+	scaleX, scaleY := element.Transform.ScaleX, element.Transform.ScaleY
+	if scaleX == 0 {
+		scaleX = 1
+	}
+	if scaleY == 0 {
+		scaleY = 1
+	}
+
+	return ImagePlacement{
+		X:      toEMU(element.Transform.TranslateX, element.Transform.Unit),
+		Y:      toEMU(element.Transform.TranslateY, element.Transform.Unit),
+		Width:  toEMU(element.Size.Width.Magnitude, element.Size.Width.Unit) * scaleX,
+		Height: toEMU(element.Size.Height.Magnitude, element.Size.Height.Unit) * scaleY,
+	}, true
+}
+
+// buildImageRequests places the image on the slide, with the caption and,
+// when asked, the credit line stacked right underneath it. A layout with a
+// picture placeholder gets the image put in that, cropped to fill it,
+// rather than one floating on top.
+func buildImageRequests(slide *slides.Page, index int, image SlideImage, caption string, withCredit bool) []*slides.Request {
+	slideId := slide.ObjectId
+	placement := placeImage(image, options.ImageFit)
+	var requests []*slides.Request
+	if picture := picturePlaceholder(slide); picture != nil {
+		requests = []*slides.Request{
+			{
+				ReplaceImage: &slides.ReplaceImageRequest{
+					ImageObjectId:      picture.ObjectId,
+					Url:                image.URL,
+					ImageReplaceMethod: "CENTER_CROP",
+				},
+			},
+		}
+		if box, ok := elementPlacement(picture); ok {
+			placement = box
+		}
+	} else {
+		requests = []*slides.Request{
+			{
+				CreateImage: &slides.CreateImageRequest{
+					Url:               image.URL,
+					ElementProperties: elementProperties(slideId, placement),
+				},
+			},
+		}
+	}
+
+	below := ImagePlacement{
+		X:      placement.X,
+		Y:      placement.Y + placement.Height,
+		Width:  placement.Width,
+		Height: IMAGE_TEXT_LINE_HEIGHT,
+	}
+	if caption != "" {
+		captionId := fmt.Sprintf("image_caption_%d", index)
+		requests = append(requests, buildSmallTextBoxRequests(captionId, slideId, below, caption, 10, mutedColor())...)
+		below.Y += IMAGE_TEXT_LINE_HEIGHT
+	}
+	if withCredit && image.Credit != "" {
+		creditId := fmt.Sprintf("image_credit_%d", index)
+		requests = append(requests, buildSmallTextBoxRequests(creditId, slideId, below, image.Credit, 8, nil)...)
+	}
+
+	return requests
+}
+
+// buildSmallTextBoxRequests makes a text box for small print. A nil color
+// leaves the text the theme's normal color.
+func buildSmallTextBoxRequests(objectId string, slideId string, placement ImagePlacement, text string, fontSize float64, color *slides.OptionalColor) []*slides.Request {
+	style := &slides.TextStyle{
+		FontSize: &slides.Dimension{Magnitude: fontSize, Unit: "PT"},
+	}
+	fields := "fontSize"
+	if color != nil {
+		style.ForegroundColor = color
+		fields = "fontSize,foregroundColor"
+	}
+
+	return []*slides.Request{
+		{
+			CreateShape: &slides.CreateShapeRequest{
+				ObjectId:          objectId,
+				ShapeType:         "TEXT_BOX",
+				ElementProperties: elementProperties(slideId, placement),
+			},
+		},
+		{
+			InsertText: &slides.InsertTextRequest{
+				ObjectId: objectId,
+				Text:     text,
+			},
+		},
+		{
+			UpdateTextStyle: &slides.UpdateTextStyleRequest{
+				ObjectId: objectId,
+				Style:    style,
+				Fields:   fields,
+			},
+		},
+	}
+}
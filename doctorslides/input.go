@@ -0,0 +1,144 @@
+package doctorslides
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// STDIN_ARGUMENT in place of a document ID reads the text from stdin
+const STDIN_ARGUMENT = "-"
+
+// How long a title taken from the text's first line can be
+const TEXT_TITLE_CHARS = 60
+
+// CLIPBOARD_COMMANDS are tried in order for --from-clipboard. Linux has a few
+// depending on the desktop, so it tries each of them.
+var CLIPBOARD_COMMANDS = map[string][][]string{
+	"darwin":  {{"pbpaste"}},
+	"windows": {{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard"}},
+	"linux": {
+		{"wl-paste", "--no-newline"},
+		{"xclip", "-selection", "clipboard", "-o"},
+		{"xsel", "--clipboard", "--output"},
+	},
+}
+
+// readsText is true when the text comes from stdin, the clipboard, or a
+// Markdown file instead of a Google Doc
+func readsText(positional []string) bool {
+	return options.FromClipboard || isMarkdownFile(positional) || (len(positional) > 0 && positional[0] == STDIN_ARGUMENT)
+}
+
+func readStdinText() string {
+	logln("Reading the text from stdin")
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fatalf("Could not read from stdin: %s", err)
+	}
+
+	return string(content)
+}
+
+func readClipboardText() string {
+	logln("Reading the text from the clipboard")
+	commands, ok := CLIPBOARD_COMMANDS[runtime.GOOS]
+	if !ok {
+		fatalf("I don't know how to read the clipboard on %s. Try piping the text in with \"-\" instead.", runtime.GOOS)
+	}
+	tried := make([]string, 0)
+	for _, command := range commands {
+		if _, err := exec.LookPath(command[0]); err != nil {
+			tried = append(tried, command[0])
+			continue
+		}
+		content, err := exec.Command(command[0], command[1:]...).Output()
+		if err != nil {
+			fatalf("Could not read the clipboard with %s: %s", command[0], err)
+		}
+		return string(content)
+	}
+
+	fatalf("Could not find a clipboard tool (tried %s). Try piping the text in with \"-\" instead.", strings.Join(tried, ", "))
+
+	return ""
+}
+
+// titleFromText names the deck after the first line with anything on it
+func titleFromText(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(line, "# \t"))
+		if line != "" {
+			title, _ := truncateBullet(line, TEXT_TITLE_CHARS)
+			return title
+		}
+	}
+
+	return ""
+}
+
+// buildOutlineFromText is buildOutlineFromDocument for text that didn't
+// come from a doc, so there's no Docs call and nothing to sign in to
+func buildOutlineFromText(text string, source string) GPTOutline {
+	textContent := cleanUpText(text)
+	if strings.TrimSpace(textContent) == "" {
+		fatalf("There's no text to work with from %s.", source)
+	}
+	parsedOutline := outlineFromText(textContent)
+	parsedOutline.Title = titleFromText(text)
+
+	return postProcessOutline(parsedOutline)
+}
+
+// buildOutline reads from wherever the arguments say to: the clipboard,
+// stdin, a Markdown file, or a Google Doc
+func buildOutline(positional []string) GPTOutline {
+	var outline GPTOutline
+	switch {
+	case options.FromClipboard:
+		outline = buildOutlineFromText(readClipboardText(), "the clipboard")
+	case positional[0] == STDIN_ARGUMENT:
+		outline = buildOutlineFromText(readStdinText(), "stdin")
+	case isMarkdownFile(positional):
+		outline = buildOutlineFromMarkdown(positional[0])
+	default:
+		outline = buildOutlineFromDocument(positional[0])
+	}
+	if options.Title != "" {
+		outline.Title = options.Title
+	}
+
+	return outline
+}
+
+// validateTextInput catches the flags that need a real doc to work with
+func validateTextInput(positional []string) {
+	if options.FromClipboard && len(positional) > 0 {
+		fatalf("--from-clipboard reads the clipboard, so it doesn't take a document ID too.")
+	}
+	if !readsText(positional) {
+		return
+	}
+	options.readsText = true
+	if isMarkdownFile(positional) {
+		options.readsMarkdown = true
+		if options.SplitOnHeadings || options.ReadMode == "export" || len(options.inputHeadings) > 0 {
+			fatalf("A Markdown file is already split up by its headings, so it can't be used with --split-on-headings, --read-mode export, or --input-headings.")
+		}
+		return
+	}
+	if options.NoAI || options.SplitOnHeadings || options.ReadMode == "export" || len(options.inputHeadings) > 0 {
+		fatalf("There are no headings in plain text, so %s can't be used with --no-ai, --split-on-headings, --read-mode export, or --input-headings.", describeTextInput())
+	}
+}
+
+func describeTextInput() string {
+	if options.FromClipboard {
+		return "--from-clipboard"
+	}
+
+	return fmt.Sprintf("reading from stdin with \"%s\"", STDIN_ARGUMENT)
+}
@@ -0,0 +1,71 @@
+package doctorslides
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// cancelCTX cancels CTX, which is what Ctrl+C does unless something has
+// taken it over with onInterrupt
+var cancelCTX context.CancelFunc = func() {}
+
+var (
+	interruptMutex   sync.Mutex
+	interruptHandler func()
+)
+
+// catchInterrupts sets CTX up to be cancelled by Ctrl+C, so whatever is
+// running gets the chance to stop cleanly. Hitting it again once CTX is
+// cancelled quits on the spot, for anything that isn't listening.
+func catchInterrupts() func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	CTX, cancelCTX = ctx, cancel
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		for range interrupts {
+			handleInterrupt()
+		}
+	}()
+
+	return func() {
+		signal.Stop(interrupts)
+		cancel()
+	}
+}
+
+// EXIT_INTERRUPTED is the exit code for giving up on a second Ctrl+C, the
+// same one a shell reports for a program killed by SIGINT
+const EXIT_INTERRUPTED = 130
+
+func handleInterrupt() {
+	if CTX.Err() != nil {
+		logln("Stopping now")
+		os.Exit(EXIT_INTERRUPTED)
+	}
+	interruptMutex.Lock()
+	handler := interruptHandler
+	interruptMutex.Unlock()
+	if handler != nil {
+		handler()
+		return
+	}
+	cancelCTX()
+}
+
+// onInterrupt has Ctrl+C call handler instead of cancelling CTX, until the
+// function it hands back is called
+func onInterrupt(handler func()) func() {
+	interruptMutex.Lock()
+	defer interruptMutex.Unlock()
+	saved := interruptHandler
+	interruptHandler = handler
+
+	return func() {
+		interruptMutex.Lock()
+		defer interruptMutex.Unlock()
+		interruptHandler = saved
+	}
+}
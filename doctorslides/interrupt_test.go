@@ -0,0 +1,24 @@
+package doctorslides
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOnInterrupt(t *testing.T) {
+	savedCTX, savedCancel := CTX, cancelCTX
+	t.Cleanup(func() { CTX, cancelCTX = savedCTX, savedCancel })
+	CTX, cancelCTX = context.WithCancel(context.Background())
+
+	handled := 0
+	restore := onInterrupt(func() { handled++ })
+	handleInterrupt()
+	if handled != 1 || CTX.Err() != nil {
+		t.Fatalf("the handler ran %d times and CTX is %v, want it run once and CTX left alone", handled, CTX.Err())
+	}
+	restore()
+	handleInterrupt()
+	if handled != 1 || CTX.Err() == nil {
+		t.Errorf("after restoring, Ctrl+C should cancel CTX and not call the handler")
+	}
+}
@@ -0,0 +1,154 @@
+package doctorslides
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// STOPWORDS are a handful of the most common little words in each language
+// written in the Latin alphabet. Counting them is crude, but a doc has
+// plenty of them and they barely overlap.
+var STOPWORDS = map[string][]string{
+	"en": {"the", "and", "is", "are", "of", "to", "that", "with", "for", "this", "it", "was"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "mit", "ein", "eine", "zu", "auf", "sich"},
+	"es": {"el", "la", "los", "las", "y", "es", "que", "de", "en", "por", "para", "una"},
+	"fr": {"le", "la", "les", "et", "est", "que", "des", "une", "pour", "dans", "pas", "sur"},
+	"it": {"il", "lo", "gli", "e", "che", "di", "per", "una", "non", "sono", "della", "nel"},
+	"nl": {"de", "het", "een", "en", "is", "van", "dat", "niet", "op", "voor", "met", "zijn"},
+	"pt": {"o", "os", "as", "e", "que", "de", "em", "um", "uma", "para", "com", "não"},
+}
+
+// Not enough of a lead means it's a guess, and a wrong guess would have GPT
+// translate the doc, which is just what this is meant to avoid
+const (
+	MIN_STOPWORD_HITS  = 5
+	MIN_STOPWORD_LEAD  = 1.5
+	MIN_SCRIPT_LETTERS = 20
+)
+
+// detectLanguage guesses what language a doc is written in, going by the
+// alphabet for the languages that have their own and by common words for
+// the rest. It gives up rather than guess when it isn't clear.
+func detectLanguage(text string) (string, bool) {
+	if lang, ok := detectScript(text); ok {
+		return lang, true
+	}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	counts := make(map[string]int)
+	for lang, stopwords := range STOPWORDS {
+		for _, word := range words {
+			if isOneOf(word, stopwords) {
+				counts[lang]++
+			}
+		}
+	}
+	langs := make([]string, 0)
+	for lang := range counts {
+		langs = append(langs, lang)
+	}
+	if len(langs) == 0 {
+		return "", false
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if counts[langs[i]] == counts[langs[j]] {
+			return langs[i] < langs[j]
+		}
+		return counts[langs[i]] > counts[langs[j]]
+	})
+	best := counts[langs[0]]
+	if best < MIN_STOPWORD_HITS {
+		return "", false
+	}
+	if len(langs) > 1 && float64(best) < MIN_STOPWORD_LEAD*float64(counts[langs[1]]) {
+		return "", false
+	}
+
+	return langs[0], true
+}
+
+// detectScript picks out languages by their alphabet. Japanese mixes kana in
+// with its kanji, so any kana at all means Japanese rather than Chinese, and
+// Persian and Urdu have a few letters Arabic doesn't use.
+func detectScript(text string) (string, bool) {
+	counts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case strings.ContainsRune("ٹڈڑےںھ", r):
+			counts["ur"]++
+		case strings.ContainsRune("پچژگی", r):
+			counts["fa"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Hebrew, r):
+			counts["he"]++
+		case unicode.Is(unicode.Devanagari, r):
+			counts["hi"]++
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			counts["ja"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Han, r):
+			counts["zh"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["ru"]++
+		}
+	}
+	if letters == 0 {
+		return "", false
+	}
+	arabicScript := counts["ar"] + counts["fa"] + counts["ur"]
+	if arabicScript >= MIN_SCRIPT_LETTERS && arabicScript*2 > letters {
+		if counts["ur"] > 0 {
+			return "ur", true
+		}
+		if counts["fa"] > 0 {
+			return "fa", true
+		}
+		return "ar", true
+	}
+	if counts["ja"] > 0 && counts["ja"]+counts["zh"] >= MIN_SCRIPT_LETTERS && (counts["ja"]+counts["zh"])*2 > letters {
+		return "ja", true
+	}
+	for _, lang := range []string{"he", "hi", "ko", "zh", "ru"} {
+		if counts[lang] >= MIN_SCRIPT_LETTERS && counts[lang]*2 > letters {
+			return lang, true
+		}
+	}
+
+	return "", false
+}
+
+// detectDocumentLanguage sets the language for this doc when --lang wasn't
+// given. Docs in a folder run side by side share the one setting, so it's
+// only done there when they go one at a time.
+func detectDocumentLanguage(text string) {
+	if options.explicit["lang"] {
+		return
+	}
+	if options.FromFolder != "" && options.Concurrency > 1 {
+		if DEBUG {
+			logln("Not detecting the language with --concurrency above 1, pass --lang if the docs aren't in English")
+		}
+		return
+	}
+	lang, found := detectLanguage(text)
+	if !found {
+		options.Lang = ""
+		if DEBUG {
+			logln("Couldn't tell what language the document is in")
+		}
+		return
+	}
+	options.Lang = lang
+	if DEBUG {
+		logf("The document looks like it's in %s\n", languageName(lang))
+	}
+}
@@ -0,0 +1,140 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/slides/v1"
+	"strings"
+)
+
+// LANGUAGE_NAMES are the names GPT gets told for the common --lang codes.
+// Codes that aren't in here get passed along as they are.
+var LANGUAGE_NAMES = map[string]string{
+	"ar": "Arabic",
+	"de": "German",
+	"en": "English",
+	"es": "Spanish",
+	"fa": "Persian",
+	"fr": "French",
+	"he": "Hebrew",
+	"hi": "Hindi",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"ur": "Urdu",
+	"zh": "Chinese",
+}
+
+// RTL_LANGUAGES are written right to left
+var RTL_LANGUAGES = []string{"ar", "dv", "fa", "he", "ps", "ur", "yi"}
+
+// baseLanguage turns codes like "ar-EG" into just "ar"
+func baseLanguage(lang string) string {
+	base, _, _ := strings.Cut(strings.ToLower(lang), "-")
+
+	return base
+}
+
+func languageName(lang string) string {
+	if name, ok := LANGUAGE_NAMES[baseLanguage(lang)]; ok {
+		return name
+	}
+
+	return lang
+}
+
+func languageInstruction() string {
+	instruction := ""
+	if options.Lang != "" {
+		instruction = fmt.Sprintf(" Write the whole slideshow in %s, whatever language the document is in.", languageName(options.Lang))
+	}
+	if notesInOtherLanguage() {
+		instruction += fmt.Sprintf(" Write the speaker notes in %s though.", languageName(options.NotesLang))
+	}
+
+	return instruction
+}
+
+// notesLanguage is the language the speaker notes go in, which is the
+// slides' language unless --notes-lang says otherwise
+func notesLanguage() string {
+	if options.NotesLang != "" {
+		return options.NotesLang
+	}
+
+	return options.Lang
+}
+
+func notesInOtherLanguage() bool {
+	return options.NotesLang != "" && baseLanguage(options.NotesLang) != baseLanguage(options.Lang)
+}
+
+// translateNotes puts notes that didn't come from GPT, like the ones made
+// from the bullets, into the --notes-lang. That costs a call per slide. A
+// slide keeps its notes as they were if the call fails.
+func translateNotes(outline GPTOutline) GPTOutline {
+	if !notesInOtherLanguage() {
+		return outline
+	}
+	logf("Asking GPT to translate the speaker notes into %s\n", languageName(options.NotesLang))
+	for i := range outline.Slides {
+		translateSlideNotes(&outline.Slides[i])
+	}
+
+	return outline
+}
+
+func translateSlideNotes(slide *SimpleSlide) {
+	if slide.Notes == "" {
+		return
+	}
+	translated, err := translateText(slide.Notes, options.NotesLang)
+	if err != nil {
+		logf("Could not translate the notes for \"%s\", leaving them as they are: %s\n", slide.Title, err)
+		return
+	}
+	slide.Notes = strings.TrimSpace(translated)
+}
+
+func translateText(text string, lang string) (string, error) {
+	template := `
+	Translate these speaker notes into %s. Keep the line breaks where they
+	are and don't add anything else.
+
+	%s`
+
+	return askGPT(fmt.Sprintf(template, languageName(lang), text))
+}
+
+func isRightToLeft(lang string) bool {
+	return isOneOf(baseLanguage(lang), RTL_LANGUAGES)
+}
+
+// buildDirectionRequests flips the text in the given boxes to right to left
+// when --lang is a right to left language. START alignment follows the
+// direction, so that puts the text on the right as well.
+func buildDirectionRequests(objectIds ...string) []*slides.Request {
+	if !isRightToLeft(options.Lang) {
+		return nil
+	}
+	requests := make([]*slides.Request, 0)
+	for _, objectId := range objectIds {
+		requests = append(requests, &slides.Request{
+			UpdateParagraphStyle: &slides.UpdateParagraphStyleRequest{
+				ObjectId: objectId,
+				TextRange: &slides.Range{
+					Type: "ALL",
+				},
+				Style: &slides.ParagraphStyle{
+					Direction: "RIGHT_TO_LEFT",
+					Alignment: "START",
+				},
+				Fields: "direction,alignment",
+			},
+		})
+	}
+
+	return requests
+}
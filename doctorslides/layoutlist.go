@@ -0,0 +1,73 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/slides/v1"
+	"sort"
+	"strings"
+)
+
+// listLayouts prints the layouts a deck has to offer, with the placeholders
+// on each one, so you can tell what --content-layout and --closing-layout
+// will actually give you. Without a deck to look at it makes an empty one,
+// which has the layouts every new deck gets.
+func listLayouts(presentationId string) {
+	slidesService := getSlidesService()
+	var presentation *slides.Presentation
+	var err error
+	if presentationId == "" {
+		presentation, err = slidesService.Presentations.Create(&slides.Presentation{Title: "Doctor Slides layouts"}).Context(CTX).Do()
+		if err != nil {
+			logln("Could not create a deck to look at")
+			panic(err)
+		}
+		logf("Made an empty deck to look at, delete it whenever: %s\n", presentationURL(presentation.PresentationId))
+	} else {
+		presentation, err = slidesService.Presentations.Get(presentationId).Context(CTX).Do()
+		if err != nil {
+			logln("Could not read the presentation")
+			panic(err)
+		}
+	}
+
+	for _, layout := range presentation.Layouts {
+		name, displayName := "", ""
+		if layout.LayoutProperties != nil {
+			name = layout.LayoutProperties.Name
+			displayName = layout.LayoutProperties.DisplayName
+		}
+		predefined := "not predefined"
+		if isOneOf(name, PREDEFINED_LAYOUTS) {
+			predefined = "use --content-layout " + name
+		}
+		fmt.Printf("%s \"%s\" (%s)\n", name, displayName, predefined)
+		fmt.Printf("  %s\n", describePlaceholders(layout))
+	}
+}
+
+// describePlaceholders sums up the placeholders on a layout by type, with the
+// index of each, since layouts with two of a kind tell them apart that way
+func describePlaceholders(layout *slides.Page) string {
+	indexes := make(map[string][]string)
+	for _, element := range layout.PageElements {
+		if element.Shape == nil || element.Shape.Placeholder == nil {
+			continue
+		}
+		placeholder := element.Shape.Placeholder
+		indexes[placeholder.Type] = append(indexes[placeholder.Type], fmt.Sprint(placeholder.Index))
+	}
+	if len(indexes) == 0 {
+		return "no placeholders"
+	}
+	types := make([]string, 0)
+	for placeholderType := range indexes {
+		types = append(types, placeholderType)
+	}
+	sort.Strings(types)
+	parts := make([]string, 0)
+	for _, placeholderType := range types {
+		parts = append(parts, fmt.Sprintf("%s x%d (index %s)", placeholderType, len(indexes[placeholderType]), strings.Join(indexes[placeholderType], ", ")))
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,73 @@
+package doctorslides
+
+import (
+	"google.golang.org/api/slides/v1"
+)
+
+// PREDEFINED_LAYOUTS are the layouts Slides lets you ask for by name
+var PREDEFINED_LAYOUTS = []string{
+	"BLANK",
+	"CAPTION_ONLY",
+	"TITLE",
+	"TITLE_AND_BODY",
+	"TITLE_AND_TWO_COLUMNS",
+	"TITLE_ONLY",
+	"SECTION_HEADER",
+	"SECTION_TITLE_AND_DESCRIPTION",
+	"ONE_COLUMN_TEXT",
+	"MAIN_POINT",
+	"BIG_NUMBER",
+}
+
+// Where the title and body go when the layout doesn't have a spot for them,
+// in EMU. These match where the default theme puts its own placeholders on
+// a 16:9 page, titleBox and bodyBox are them fitted to the deck's size.
+var (
+	TITLE_BOX = ImagePlacement{X: 311700, Y: 445025, Width: 8520600, Height: 572700}
+	BODY_BOX  = ImagePlacement{X: 311700, Y: 1152475, Width: 8520600, Height: 3416400}
+)
+
+// findTextBox looks for the placeholder a piece of text should go in. Layouts
+// without one get a text box of our own instead, named after the slide so we
+// can find it again when the slide is updated later. The element is nil when
+// neither exists yet, and the ID is the one to create it with.
+func findTextBox(slide *slides.Page, name string, placeholderTypes ...string) (*slides.PageElement, string) {
+	if placeholder := findPlaceholder(slide, placeholderTypes...); placeholder != nil {
+		return placeholder, placeholder.ObjectId
+	}
+	boxId := slide.ObjectId + "_" + name
+	for _, element := range slide.PageElements {
+		if element.ObjectId == boxId {
+			return element, boxId
+		}
+	}
+
+	return nil, boxId
+}
+
+// ensureTextBox hands back the ID to insert text into, along with the
+// requests to make the box first if the layout didn't come with one
+func ensureTextBox(slide *slides.Page, name string, placement ImagePlacement, placeholderTypes ...string) (string, []*slides.Request) {
+	element, boxId := findTextBox(slide, name, placeholderTypes...)
+	if element != nil {
+		return boxId, nil
+	}
+
+	return boxId, []*slides.Request{
+		{
+			CreateShape: &slides.CreateShapeRequest{
+				ObjectId:          boxId,
+				ShapeType:         "TEXT_BOX",
+				ElementProperties: elementProperties(slide.ObjectId, placement),
+			},
+		},
+	}
+}
+
+func contentTitleBox(slide *slides.Page) (string, []*slides.Request) {
+	return ensureTextBox(slide, "title", titleBox(), "TITLE", "CENTERED_TITLE")
+}
+
+func contentBodyBox(slide *slides.Page) (string, []*slides.Request) {
+	return ensureTextBox(slide, "body", bodyBox(), "BODY", "SUBTITLE")
+}
@@ -0,0 +1,31 @@
+package doctorslides
+
+import (
+	"google.golang.org/api/option"
+	"io"
+	"log"
+	"os"
+)
+
+// routeLibraryLogs sends anything the Google client libraries (or anything
+// else) print through the standard log package to stderr with a prefix, so
+// stdout stays ours. With quiet set it's dropped entirely unless DEBUG is on.
+func routeLibraryLogs(quiet bool) {
+	log.SetPrefix("[library] ")
+	log.SetFlags(0)
+	if quiet && !DEBUG {
+		log.SetOutput(io.Discard)
+		return
+	}
+	log.SetOutput(os.Stderr)
+}
+
+// googleServiceOptions are the options every Google service gets made with
+func googleServiceOptions() []option.ClientOption {
+	serviceOptions := []option.ClientOption{option.WithHTTPClient(getGoogleClient())}
+	if options.QuietGoogle {
+		serviceOptions = append(serviceOptions, option.WithTelemetryDisabled())
+	}
+
+	return serviceOptions
+}
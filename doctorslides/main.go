@@ -0,0 +1,1517 @@
+package doctorslides
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofor-little/env"
+	"github.com/sashabaranov/go-openai"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/slides/v1"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	DEBUG               bool
+	GOOGLE_API_KEY      string
+	OPEN_AI_KEY         string
+	OPENAI_ORG_ID       string
+	OPENAI_PROJECT      string
+	UNSPLASH_ACCESS_KEY string
+	PEXELS_API_KEY      string
+	MS_CLIENT_ID        string
+	MS_CLIENT_SECRET    string
+	MS_TENANT           string
+	MS_REDIRECT_URL     string
+)
+
+// Bullet is one line of slide content. Level is how deeply it's nested, with
+// 0 being a top-level point.
+type Bullet struct {
+	Text     string     `json:"text"`
+	Level    int        `json:"level,omitempty"`
+	Emphasis []Emphasis `json:"emphasis,omitempty"`
+	Checkbox bool       `json:"checkbox,omitempty"`
+	Checked  bool       `json:"checked,omitempty"`
+}
+
+type SimpleSlide struct {
+	Title   string   `json:"title"`
+	Bullets []Bullet `json:"bullets"`
+	Image   string   `json:"image,omitempty"`
+	Notes   string   `json:"notes,omitempty"`
+	// Caption only gets shown when the slide ends up with an image
+	Caption string `json:"caption,omitempty"`
+	// Table is rows of cells for slides comparing things side by side
+	Table [][]string `json:"table,omitempty"`
+	// Code is a snippet shown in a monospace box of its own, line breaks and
+	// indentation included
+	Code string `json:"code,omitempty"`
+	// Video is a YouTube link or ID, played where the image would go
+	Video string `json:"video,omitempty"`
+	// Section is the heading of the part of the doc the slide came from, for
+	// --color-sections
+	Section string `json:"section,omitempty"`
+	// Background is a hex or named color to fill the slide's background with
+	Background string `json:"background,omitempty"`
+	// DocImage is a picture from the doc for --image-source doc, which
+	// expires not long after the doc is read
+	DocImage string `json:"docImage,omitempty"`
+}
+
+type GPTOutline struct {
+	Title    string        `json:"title"`
+	Subtitle string        `json:"subtitle,omitempty"`
+	Slides   []SimpleSlide `json:"slides"`
+	// SourceURL is the doc the outline was made from
+	SourceURL string `json:"sourceUrl,omitempty"`
+}
+
+// LOG is where all of the status chatter goes. It's normally stdout, but gets
+// pointed at stderr when stdout is reserved for something a script will read.
+var LOG io.Writer = os.Stdout
+
+func logln(a ...any) {
+	fmt.Fprintln(LOG, a...)
+}
+
+func logf(format string, a ...any) {
+	fmt.Fprintf(LOG, format, a...)
+}
+
+// fatalf reports something we can't get past and quits. With --json-result
+// the message goes out as the JSON error too so scripts can see why.
+func fatalf(format string, a ...any) {
+	fatalWithCode(1, format, a...)
+}
+
+// fatalWithCode is fatalf for the failures scripts might want to tell apart
+// by exit code
+func fatalWithCode(code int, format string, a ...any) {
+	message := fmt.Sprintf(format, a...)
+	if options.collectFailures {
+		panic(newFailure(message, a))
+	}
+	logln(message)
+	if options.JSONResult {
+		printJSONError(message)
+	}
+	os.Exit(code)
+}
+
+// failure is what fatalf panics with when it isn't quitting. It reads the
+// same as the message would have, but still unwraps to the error that caused
+// it so errors.Is can pick out things like ErrDocNotFound.
+type failure struct {
+	message string
+	err     error
+}
+
+func (f *failure) Error() string { return f.message }
+
+func (f *failure) Unwrap() error { return f.err }
+
+func newFailure(message string, a []any) *failure {
+	f := &failure{message: message}
+	for _, arg := range a {
+		if err, ok := arg.(error); ok {
+			f.err = err
+			break
+		}
+	}
+
+	return f
+}
+
+// panicError turns whatever a recover() caught back into an error, leaving
+// errors as they are so they can still be unwrapped
+func panicError(r any) error {
+	if err, ok := r.(error); ok {
+		return err
+	}
+
+	return fmt.Errorf("%v", r)
+}
+
+// Exit codes for the failures that have one of their own. Anything else
+// exits with 1.
+const (
+	EXIT_DOC_NOT_FOUND = 3
+	EXIT_DOC_NO_ACCESS = 4
+)
+
+// exitCode picks the exit code for an error we're giving up on
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, ErrDocNotFound):
+		return EXIT_DOC_NOT_FOUND
+	case errors.Is(err, ErrDocNoAccess):
+		return EXIT_DOC_NO_ACCESS
+	}
+
+	return 1
+}
+
+const GPT_MODEL = openai.GPT3Dot5Turbo
+const TOKEN_FILE = "token.json"
+const CREDENTIALS_FILE = "./credentials.json"
+const EXAMPLE_OUTLINE_FILE = "./exampleOutline.txt"
+
+func init() {
+	readEnv()
+}
+
+// LoadEnv reads a .env file into the environment and picks the settings up
+// from it. The command line loads ./.env at startup; programs using the
+// package only get the environment they were started with unless they call
+// this themselves.
+func LoadEnv(path string) error {
+	err := env.Load(path)
+	readEnv()
+
+	return err
+}
+
+func readEnv() {
+	DEBUG = strings.ToLower(env.Get("DEBUG", "false")) == "true"
+	GOOGLE_API_KEY = env.Get("GOOGLE_API_KEY", "[NO API KEY]")
+	OPEN_AI_KEY = env.Get("OPEN_AI_KEY", "")
+	OPENAI_ORG_ID = env.Get("OPENAI_ORG_ID", "")
+	OPENAI_PROJECT = env.Get("OPENAI_PROJECT", "")
+	UNSPLASH_ACCESS_KEY = env.Get("UNSPLASH_ACCESS_KEY", "")
+	PEXELS_API_KEY = env.Get("PEXELS_API_KEY", "")
+	MS_CLIENT_ID = env.Get("MS_CLIENT_ID", "")
+	MS_CLIENT_SECRET = env.Get("MS_CLIENT_SECRET", "")
+	MS_TENANT = env.Get("MS_TENANT", "common")
+	MS_REDIRECT_URL = env.Get("MS_REDIRECT_URL", "http://localhost")
+}
+
+// CTX gets cancelled when someone hits Ctrl+C so anything long running can
+// stop early. --watch takes the first Ctrl+C for itself, see onInterrupt.
+var CTX = context.Background()
+
+// Main is the doctor_slides command line. The binary's main does nothing
+// but call it.
+func Main() {
+	LoadEnv("./.env")
+	stop := catchInterrupts()
+	defer stop()
+	// First arg is the program, everything after is for the subcommands
+	runCLI(os.Args[1:])
+}
+
+// requireOpenAIKey is only checked by the commands that talk to GPT, so that
+// things like "version" and "auth login" work without one. --no-ai never
+// talks to GPT either, and neither does a Markdown file unless it's being
+// refined or its notes translated.
+func requireOpenAIKey() {
+	if options.readsMarkdown && !options.Refine && options.NotesLang == "" {
+		return
+	}
+	if openAIKey() == "" && !options.NoAI {
+		panic(fmt.Errorf("required env variable OPEN_AI_KEY not set"))
+	}
+}
+
+// openAIKey is the key set in the options when there is one, and
+// OPEN_AI_KEY otherwise
+func openAIKey() string {
+	if options.OpenAIKey != "" {
+		return options.OpenAIKey
+	}
+
+	return OPEN_AI_KEY
+}
+
+func buildOutlineFromDocument(documentId string) GPTOutline {
+	document, err := getGoogleDocWithId(documentId)
+	if err != nil {
+		fatalWithCode(exitCode(err), "%s", err)
+	}
+	if options.NoAI {
+		// Nothing gets translated, but right to left docs still need laying
+		// out that way
+		detectDocumentLanguage(readTextFromDocument(document))
+		parsedOutline := outlineFromHeadings(document)
+		parsedOutline.Title = document.Title
+		parsedOutline.SourceURL = documentURL(documentId)
+		return attachDocImages(addFootnotesSlide(postProcessOutline(parsedOutline), document), document)
+	}
+	var textContent string
+	if options.ReadMode == "export" {
+		textContent = exportDocumentText(documentId, options.ExportMimeType)
+	} else if len(options.inputHeadings) > 0 {
+		textContent, err = readSectionsFromDocument(document, options.inputHeadings)
+		if err != nil {
+			fatalf("%s", err)
+		}
+	} else {
+		textContent = readTextFromDocument(document)
+	}
+	textContent = cleanUpText(textContent)
+	if err := emptyDocumentError(document, textContent); err != nil {
+		fatalf("%s", err)
+	}
+	parsedOutline := outlineFromText(textContent)
+	parsedOutline.Title = document.Title
+	parsedOutline.SourceURL = documentURL(documentId)
+
+	// The footnotes go on after the post processing so they never get merged
+	// or cut down like the content
+	return attachDocImages(addFootnotesSlide(postProcessOutline(parsedOutline), document), document)
+}
+
+// emptyDocumentError is why a doc can't be turned into slides when there's
+// no text left in it, or nil if there's some. GPT will happily make
+// something up from nothing, so there's no point paying for that.
+func emptyDocumentError(document *docs.Document, textContent string) error {
+	if strings.TrimSpace(textContent) != "" {
+		return nil
+	}
+	if len(document.InlineObjects) > 0 || len(document.PositionedObjects) > 0 {
+		return errors.New("The document appears to have no readable text. It looks like it's only images, and I can only work with text.")
+	}
+
+	return errors.New("The document appears to have no readable text.")
+}
+
+// cleanUpText strips the boilerplate out of the text, and squeezes it down
+// with --compact, before it goes to GPT
+func cleanUpText(textContent string) string {
+	if !options.NoStrip {
+		textContent = stripBoilerplate(textContent, boilerplatePatterns(options.StripPatterns))
+	}
+	if options.Compact {
+		before := estimateTokens(textContent)
+		textContent = compactText(textContent)
+		if DEBUG {
+			logf("--compact took the doc from about %d tokens to about %d\n", before, estimateTokens(textContent))
+		}
+	}
+
+	return textContent
+}
+
+// outlineFromText is where the text ends up wherever it came from
+func outlineFromText(textContent string) GPTOutline {
+	detectDocumentLanguage(textContent)
+	outline := generateOutline(textContent)
+	checkSections(textContent, outline)
+
+	return outline
+}
+
+func getDocsService() *docs.Service {
+	docsService, err := docs.NewService(CTX, googleServiceOptions()...)
+	if err != nil {
+		logln("could not create Google Docs client")
+		panic(err)
+	}
+
+	return docsService
+}
+
+var (
+	ErrDocNotFound = errors.New("there's no document with that ID")
+	ErrDocNoAccess = errors.New("you don't have access to that document; share it with the account you signed in with or check the ID")
+)
+
+// getGoogleDocWithId fetches the doc. The two ways a good looking ID goes
+// wrong come back as ErrDocNotFound and ErrDocNoAccess, anything else as the
+// API's own error.
+func getGoogleDocWithId(documentId string) (*docs.Document, error) {
+	doc, err := getDocsService().Documents.Get(documentId).Context(CTX).Do()
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusNotFound:
+			return nil, fmt.Errorf("%w: %s", ErrDocNotFound, documentId)
+		case http.StatusForbidden:
+			return nil, fmt.Errorf("%w: %s", ErrDocNoAccess, documentId)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read document %s: %w", documentId, err)
+	}
+
+	logf("Obtained Document: \"%s\"\n", doc.Title)
+
+	return doc, nil
+}
+
+func readTextFromDocument(document *docs.Document) string {
+	logln("Reading the text from the document")
+	var b strings.Builder
+	eachDocumentText(document, func(text string) {
+		b.WriteString(text)
+	})
+	if options.IncludeFootnotes {
+		b.WriteString(footnotesText(document))
+	}
+
+	return b.String()
+}
+
+// eachDocumentText hands the doc's text over a piece at a time, a paragraph
+// or a whole table per call, for anything that can work through it as it goes
+// instead of needing all of it in one string first. Sections are marked for
+// --split-on-headings.
+func eachDocumentText(document *docs.Document, use func(string)) {
+	for _, bodyElement := range document.Body.Content {
+		if bodyElement.Table != nil {
+			use(tableText(tableCells(bodyElement.Table)))
+		}
+		paragraph := bodyElement.Paragraph
+		if paragraph == nil {
+			continue
+		}
+		use(sectionText(paragraph))
+	}
+}
+
+func paragraphText(paragraph *docs.Paragraph) string {
+	var b strings.Builder
+	for _, paragraphElement := range paragraph.Elements {
+		// Footnotes are marked where they're referenced so they can be
+		// matched back up to the text
+		if paragraphElement.FootnoteReference != nil && options.IncludeFootnotes {
+			b.WriteString(footnoteMarker(paragraphElement.FootnoteReference))
+		}
+		textRun := paragraphElement.TextRun
+		if textRun == nil {
+			continue
+		}
+		b.WriteString(textRun.Content)
+	}
+
+	return b.String()
+}
+
+// headingLevel reports how big a heading a paragraph is, with the doc's TITLE
+// style as 0 and HEADING_1 through HEADING_6 as 1 through 6. The bool is
+// false for paragraphs that aren't headings at all.
+func headingLevel(paragraph *docs.Paragraph) (int, bool) {
+	if paragraph.ParagraphStyle == nil {
+		return 0, false
+	}
+	style := paragraph.ParagraphStyle.NamedStyleType
+	if style == "TITLE" {
+		return 0, true
+	}
+	if strings.HasPrefix(style, "HEADING_") {
+		level, err := strconv.Atoi(strings.TrimPrefix(style, "HEADING_"))
+		return level, err == nil
+	}
+
+	return 0, false
+}
+
+// readSectionsFromDocument is readTextFromDocument for just part of the doc.
+// It keeps each named heading (matched ignoring case) and everything under it
+// up until the next heading that's the same size or bigger.
+func readSectionsFromDocument(document *docs.Document, headings []string) (string, error) {
+	logf("Reading the %s sections from the document\n", strings.Join(headings, ", "))
+	wanted := make(map[string]bool)
+	for _, heading := range headings {
+		wanted[strings.ToLower(strings.TrimSpace(heading))] = true
+	}
+
+	var text strings.Builder
+	found := 0
+	// -1 means we aren't in a section we want right now
+	sectionLevel := -1
+	for _, bodyElement := range document.Body.Content {
+		// Tables go with whichever section they sit in
+		if bodyElement.Table != nil && sectionLevel >= 0 {
+			text.WriteString(tableText(tableCells(bodyElement.Table)))
+		}
+		paragraph := bodyElement.Paragraph
+		if paragraph == nil {
+			continue
+		}
+		content := paragraphText(paragraph)
+		if level, isHeading := headingLevel(paragraph); isHeading {
+			if sectionLevel >= 0 && level <= sectionLevel {
+				sectionLevel = -1
+			}
+			if sectionLevel < 0 && wanted[strings.ToLower(strings.TrimSpace(content))] {
+				sectionLevel = level
+				found++
+			}
+		}
+		if sectionLevel >= 0 {
+			text.WriteString(sectionText(paragraph))
+		}
+	}
+	if found == 0 {
+		return "", fmt.Errorf("none of the headings %s are in the document", strings.Join(headings, ", "))
+	}
+
+	return text.String(), nil
+}
+
+// generateOutline asks GPT for an outline until it gives back something we
+// can parse, since a second try usually works out when the first doesn't.
+func generateOutline(content string) GPTOutline {
+	var candidates []string
+	for attempt := 0; attempt <= options.GarbageRetries; attempt++ {
+		if attempt > 0 {
+			logf("GPT gave me garbage. Trying again (%d of %d)\n", attempt, options.GarbageRetries)
+		}
+		// Retries get a firmer reminder about the format, since that's almost
+		// always what went wrong
+		var cutOff bool
+		candidates, cutOff = getGPTOutline(content, attempt > 0)
+		if parsedOutline, raw, ok := pickOutline(candidates); ok {
+			if cutOff {
+				logf("GPT's reply got cut off at the token limit. Recovered %d slides, expected at least %d. Try a bigger --max-tokens.\n", len(parsedOutline.Slides), expectedSlides())
+			}
+			if options.Refine {
+				parsedOutline = refineOutline(content, raw, parsedOutline)
+			}
+			return parsedOutline
+		}
+	}
+	giveUpOnGarbage(strings.Join(candidates, "\n\n"))
+
+	return GPTOutline{}
+}
+
+// getGPTOutline hands back every outline GPT wrote, which is just the one
+// unless --candidates asked for more, and whether any of them got cut off by
+// the token limit
+func getGPTOutline(content string, firm bool) ([]string, bool) {
+	logln("Asking GPT for a slides outline")
+	template := `
+	Please use the following document contents in order to build the outline of
+	a slideshow. %s Each slide should have a title, at least two content bullet points,
+	and a url for an image. Before the first slide, give one line with a short
+	subtitle for the whole slideshow like this:
+
+	{{SUBTITLE}}A short subtitle here
+
+	The outline should follow thes format for each slide:
+
+	{{SLIDE_START}}
+	{{TITLE}}The title of the slide here
+	{{BULLET}}example bullet point 1
+	{{BULLET}}example bullet point 2
+	{{BULLET}}example bullet point 3
+	{{IMAGE_URL}}https://example.com/an_image_for_this_slide.jpg
+	{{SLIDE_END}}
+
+	The document's tables are written as "{{TABLE}}" lines with the cells split
+	by "|". When a table compares things, make it a slide of its own with
+	those "{{TABLE}}" lines in place of the bullet points, keeping the header
+	row first, like this:
+
+	{{SLIDE_START}}
+	{{TITLE}}Plan comparison
+	{{TABLE}}Plan | Price | Seats
+	{{TABLE}}Basic | $10 | 1
+	{{TABLE}}Team | $50 | 10
+	{{SLIDE_END}}
+
+	When the document links a YouTube video that belongs with a slide, add a
+	"{{VIDEO}}" line to that slide with the link. Never make one up.
+
+	When a slide needs to show code, put it between "{{CODE_FENCE}}" lines inside the
+	slide exactly as the document has it, instead of turning it into bullet
+	points, like this:
+
+	{{SLIDE_START}}
+	{{TITLE}}Reading a file
+	{{BULLET}}One call reads the whole thing
+	{{CODE_FENCE}}
+	data, err := os.ReadFile("notes.txt")
+	{{CODE_FENCE}}
+	{{SLIDE_END}}
+
+	The document:
+	%s`
+	// The markers go in first so nothing in the document gets mistaken for one
+	message := fmt.Sprintf(OUTLINE_FORMAT.fillIn(template), slideCountInstruction()+sectionInstruction()+footnoteInstruction()+checklistInstruction()+notesWordsInstruction()+languageInstruction()+wordBudgetInstruction(), content)
+	if PROMPT_TEMPLATE != nil {
+		var err error
+		message, err = renderPrompt(PROMPT_TEMPLATE, content)
+		if err != nil {
+			fatalf("Could not fill in the prompt file: %s", err)
+		}
+	}
+	if firm {
+		message = message + OUTLINE_FORMAT.fillIn(`
+
+	You MUST follow the exact delimiter format above. Every slide has to start
+	with "{{SLIDE_START}}" and end with "{{SLIDE_END}}" on their own lines.`)
+	}
+	client := newOpenAIClient()
+	req := openai.ChatCompletionRequest{
+		Model: GPT_MODEL,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: message,
+			},
+		},
+	}
+	if options.Candidates > 1 {
+		req.N = options.Candidates
+	}
+	if options.MaxTokens > 0 {
+		req.MaxTokens = options.MaxTokens
+	}
+	resp := askForOutline(client, req)
+	if wasCutOff(resp) && req.MaxTokens > 0 {
+		// We picked the limit, so we can give it more room once
+		req.MaxTokens = req.MaxTokens * 2
+		logf("GPT ran out of tokens, asking again with a limit of %d\n", req.MaxTokens)
+		resp = askForOutline(client, req)
+	}
+
+	candidates := make([]string, 0)
+	for _, choice := range resp.Choices {
+		candidates = append(candidates, choice.Message.Content)
+	}
+
+	return candidates, wasCutOff(resp)
+}
+
+// askForOutline goes through CTX so Ctrl+C, or the context handed to the
+// library, stops the most expensive call we make
+func askForOutline(client *openai.Client, req openai.ChatCompletionRequest) openai.ChatCompletionResponse {
+	checkBudget()
+	resp, err := client.CreateChatCompletion(CTX, req)
+	if err != nil {
+		logln("Could not ask GPT for help")
+		panic(err)
+	}
+
+	recordUsage(resp.Usage)
+	if options.DumpGPT != "" {
+		dumpGPT(options.DumpGPT, req, resp)
+	}
+
+	return resp
+}
+
+// wasCutOff is whether GPT hit the token limit partway through
+func wasCutOff(resp openai.ChatCompletionResponse) bool {
+	for _, choice := range resp.Choices {
+		if choice.FinishReason == openai.FinishReasonLength {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expectedSlides is the fewest content slides we asked GPT for
+func expectedSlides() int {
+	if options.ExactSlides > 0 {
+		return options.ExactSlides
+	}
+
+	return options.MinSlides
+}
+
+// wordBudgetInstruction asks GPT to leave itself enough room under
+// --max-tokens to finish the last slide. A token is about three quarters of
+// a word, and we leave a little slack on top of that.
+func wordBudgetInstruction() string {
+	if options.MaxTokens <= 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" Keep the whole outline under %d words so it isn't cut off.", options.MaxTokens*3/4*9/10)
+}
+
+func slideCountInstruction() string {
+	if options.ExactSlides > 0 {
+		return fmt.Sprintf("The slideshow must have exactly %d slides.", options.ExactSlides)
+	}
+
+	return fmt.Sprintf("The slideshow must have at least %d slides, but can have up\n\tto %d.", options.MinSlides, options.MaxSlides)
+}
+
+// newOpenAIClient bills to OPENAI_ORG_ID and OPENAI_PROJECT when they're
+// set, and to the key's own defaults when they aren't. This version of the
+// client only knows about the organization, so the project goes on as a
+// header of our own.
+func newOpenAIClient() *openai.Client {
+	config := openai.DefaultConfig(openAIKey())
+	config.OrgID = OPENAI_ORG_ID
+	client := httpClient()
+	if OPENAI_PROJECT != "" {
+		client.Transport = &headerTransport{next: client.Transport, name: "OpenAI-Project", value: OPENAI_PROJECT}
+	}
+	config.HTTPClient = client
+	openAIAccountOnce.Do(func() {
+		if DEBUG {
+			logf("Using OpenAI organization %s and project %s\n", accountOrDefault(OPENAI_ORG_ID), accountOrDefault(OPENAI_PROJECT))
+		}
+	})
+
+	return openai.NewClientWithConfig(config)
+}
+
+var openAIAccountOnce sync.Once
+
+func accountOrDefault(value string) string {
+	if value == "" {
+		return "(the key's default)"
+	}
+
+	return value
+}
+
+// askGPT is for the small follow up questions we ask GPT once there's already
+// an outline, where a failure shouldn't sink the whole run
+func askGPT(message string) (string, error) {
+	checkBudget()
+	resp, err := newOpenAIClient().CreateChatCompletion(
+		CTX,
+		openai.ChatCompletionRequest{
+			Model: GPT_MODEL,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: message,
+				},
+			},
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	recordUsage(resp.Usage)
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("GPT didn't answer")
+	}
+
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+func parseGPTOutline(outline string) GPTOutline {
+	logln("Trying to make sense of what GPT said...")
+	parsedOutline := GPTOutline{}
+	parsedOutline.Slides = make([]SimpleSlide, 0)
+
+	format := OUTLINE_FORMAT
+	var currentSlide SimpleSlide
+	inSlide := false
+	// Code blocks are kept line for line, so nothing inside one gets read as
+	// a marker
+	inCode := false
+	codeIndent := ""
+	codeLines := make([]string, 0)
+	finishCode := func() {
+		if len(codeLines) > 0 {
+			if currentSlide.Code != "" {
+				currentSlide.Code = currentSlide.Code + "\n\n"
+			}
+			currentSlide.Code = currentSlide.Code + strings.Join(codeLines, "\n")
+		}
+		inCode = false
+		codeLines = make([]string, 0)
+	}
+	lines := strings.Split(outline, "\n")
+	for _, line := range lines {
+		cleanLine := strings.TrimSpace(line)
+		if inSlide && strings.HasPrefix(cleanLine, CODE_FENCE) {
+			if inCode {
+				finishCode()
+			} else {
+				inCode = true
+				codeIndent = fenceIndent(line)
+			}
+		} else if inCode {
+			codeLines = append(codeLines, codeLine(line, codeIndent))
+		} else if cleanLine == strings.TrimSpace(format.SlideStart) {
+			currentSlide = SimpleSlide{
+				Title:   UNNAMED_TITLE,
+				Bullets: make([]Bullet, 0),
+			}
+			inSlide = true
+		} else if cleanLine == strings.TrimSpace(format.SlideEnd) {
+			inSlide = false
+			if currentSlide.Table != nil {
+				currentSlide.Table = normalizeTable(currentSlide.Table)
+			}
+			parsedOutline.Slides = append(parsedOutline.Slides, currentSlide)
+		} else if strings.HasPrefix(cleanLine, format.Title) {
+			currentSlide.Title = strings.TrimPrefix(cleanLine, format.Title)
+		} else if strings.HasPrefix(cleanLine, format.Bullet) {
+			bullet := checklistBullet(strings.TrimPrefix(cleanLine, format.Bullet), indentLevel(line))
+			currentSlide.Bullets = append(currentSlide.Bullets, bullet)
+		} else if strings.HasPrefix(cleanLine, format.ImageURL) {
+			currentSlide.Image = strings.TrimPrefix(cleanLine, format.ImageURL)
+		} else if strings.HasPrefix(cleanLine, format.Table) {
+			currentSlide.Table = append(currentSlide.Table, parseTableRow(strings.TrimPrefix(cleanLine, format.Table)))
+		} else if strings.HasPrefix(cleanLine, format.Video) {
+			currentSlide.Video = strings.TrimSpace(strings.TrimPrefix(cleanLine, format.Video))
+		} else if strings.HasPrefix(cleanLine, format.Section) {
+			currentSlide.Section = strings.TrimSpace(strings.TrimPrefix(cleanLine, format.Section))
+		} else if strings.HasPrefix(cleanLine, format.Background) {
+			color := strings.TrimSpace(strings.TrimPrefix(cleanLine, format.Background))
+			if _, err := parseColor(color); err != nil {
+				logf("WARNING: Leaving the background off \"%s\": %s\n", currentSlide.Title, err)
+			} else {
+				currentSlide.Background = color
+			}
+		} else if strings.HasPrefix(cleanLine, format.Caption) {
+			currentSlide.Caption = strings.TrimPrefix(cleanLine, format.Caption)
+		} else if strings.HasPrefix(cleanLine, format.Notes) {
+			currentSlide.Notes = strings.TrimPrefix(cleanLine, format.Notes)
+		} else if strings.HasPrefix(cleanLine, format.Subtitle) {
+			parsedOutline.Subtitle = strings.TrimPrefix(cleanLine, format.Subtitle)
+		}
+	}
+	// A reply that ran out of tokens stops partway through the last slide.
+	// Whatever made it in is still worth keeping.
+	if inCode {
+		finishCode()
+	}
+	if inSlide && currentSlide.Title != UNNAMED_TITLE && (len(currentSlide.Bullets) > 0 || currentSlide.Code != "") {
+		logf("The last slide (\"%s\") was cut off, keeping what there was of it\n", currentSlide.Title)
+		if currentSlide.Table != nil {
+			currentSlide.Table = normalizeTable(currentSlide.Table)
+		}
+		parsedOutline.Slides = append(parsedOutline.Slides, currentSlide)
+	}
+
+	return parsedOutline
+}
+
+// giveUpOnGarbage is for when there's nothing usable left to try. It dumps the
+// last thing GPT said when debugging so you can see what went wrong.
+func giveUpOnGarbage(outline string) {
+	if DEBUG {
+		logln(outline)
+	}
+	fatalf("Sorry. GPT gave me garbage. I can't do anything with this. Try again?")
+}
+
+// indentLevel counts how far in a line is indented, where a tab or a pair of
+// spaces is one level.
+func indentLevel(line string) int {
+	level := 0
+	spaces := 0
+	for _, r := range line {
+		if r == '\t' {
+			level++
+		} else if r == ' ' {
+			spaces++
+		} else {
+			break
+		}
+	}
+
+	return level + spaces/2
+}
+
+// bulletTexts flattens bullets down to just their text for anything that
+// doesn't care about nesting.
+func bulletTexts(bullets []Bullet) []string {
+	texts := make([]string, 0)
+	for _, bullet := range bullets {
+		texts = append(texts, bullet.Text)
+	}
+
+	return texts
+}
+
+// bodyTextFromBullets builds the text for a slide body. Slides works out the
+// nesting from leading tabs when the bullets get applied, so each level
+// becomes a tab.
+func bodyTextFromBullets(bullets []Bullet) string {
+	lines := make([]string, 0)
+	for _, bullet := range bullets {
+		lines = append(lines, strings.Repeat("\t", bullet.Level)+bullet.Text)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func getSlidesService() *slides.Service {
+	slidesService, err := slides.NewService(CTX, googleServiceOptions()...)
+	if err != nil {
+		panic(err)
+	}
+
+	return slidesService
+}
+
+// findPlaceholder gives back the first element on the slide that's a
+// placeholder of one of the given types, or nil if there isn't one
+func findPlaceholder(slide *slides.Page, placeholderTypes ...string) *slides.PageElement {
+	for _, placeholderType := range placeholderTypes {
+		for _, element := range slide.PageElements {
+			if element.Shape == nil || element.Shape.Placeholder == nil {
+				continue
+			}
+			if element.Shape.Placeholder.Type == placeholderType {
+				return element
+			}
+		}
+	}
+
+	return nil
+}
+
+// buildContentTextRequests fills in the title and body of a content slide.
+// The content layout might not have placeholders for both, in which case
+// text boxes get made to stand in for them.
+func buildContentTextRequests(slide *slides.Page, slideOutline SimpleSlide) []*slides.Request {
+	titleId, requests := contentTitleBox(slide)
+	titleAdd := slides.Request{
+		InsertText: &slides.InsertTextRequest{
+			ObjectId: titleId,
+			Text:     slideOutline.Title,
+		},
+	}
+	slideParagraph := bodyTextFromBullets(slideOutline.Bullets)
+	if slideParagraph == "" {
+		// Slides refuses to insert empty text, and there's nothing to put
+		// bullets on anyway
+		requests = append(requests, &titleAdd)
+		return append(requests, buildDirectionRequests(titleId)...)
+	}
+	bodyId, bodyRequests := contentBodyBox(slide)
+	requests = append(requests, bodyRequests...)
+	// Checklists get checkboxes whatever the other slides have
+	checklist := isChecklist(slideOutline.Bullets) && !options.NoBullets
+	prefix := ""
+	if glyph := customBulletGlyph(); glyph != "" && !checklist {
+		slideParagraph = prefixBulletGlyph(slideParagraph, glyph)
+		prefix = glyph + " "
+	}
+	textAdd := slides.Request{
+		InsertText: &slides.InsertTextRequest{
+			ObjectId: bodyId,
+			Text:     slideParagraph,
+		},
+	}
+	requests = append(requests, &titleAdd)
+	requests = append(requests, &textAdd)
+	requests = append(requests, buildEmphasisRequests(bodyId, slideOutline.Bullets, prefix)...)
+	preset, ok := bulletPreset()
+	if checklist {
+		preset, ok = CHECKBOX_PRESET, true
+	}
+	if ok {
+		// Applying the bullets over the whole body also turns any leading
+		// tabs into nesting levels
+		bulletAdd := slides.Request{
+			CreateParagraphBullets: &slides.CreateParagraphBulletsRequest{
+				ObjectId:     bodyId,
+				BulletPreset: preset,
+				TextRange: &slides.Range{
+					Type: "ALL",
+				},
+			},
+		}
+		requests = append(requests, &bulletAdd)
+	}
+	requests = append(requests, buildDirectionRequests(titleId, bodyId)...)
+	requests = append(requests, buildBodyAlignmentRequests(bodyId)...)
+	requests = append(requests, buildAutofitRequests(bodyId, slideOutline)...)
+
+	return requests
+}
+
+// titleSlideBox finds the box the title goes in. The TITLE layout uses a
+// CENTERED_TITLE but other layouts have a plain TITLE, and failing both we
+// fall back on the first thing on the slide like we always used to. A
+// --title-layout with nothing on it at all gets a text box made instead.
+func titleSlideBox(slide *slides.Page) (string, []*slides.Request) {
+	if placeholder := findPlaceholder(slide, "CENTERED_TITLE", "TITLE"); placeholder != nil {
+		return placeholder.ObjectId, nil
+	}
+	if len(slide.PageElements) > 0 {
+		return slide.PageElements[0].ObjectId, nil
+	}
+
+	return ensureTextBox(slide, "title", titleBox())
+}
+
+func buildTitleSlideRequests(slide *slides.Page, outline GPTOutline) []*slides.Request {
+	titleId, requests := titleSlideBox(slide)
+	requests = append(requests, &slides.Request{
+		InsertText: &slides.InsertTextRequest{
+			ObjectId: titleId,
+			Text:     outline.Title,
+		},
+	})
+	requests = append(requests, buildDirectionRequests(titleId)...)
+	subtitle := outline.Subtitle
+	if options.Subtitle != "" {
+		subtitle = options.Subtitle
+	}
+	if subtitle == "" {
+		return requests
+	}
+	placeholder := findPlaceholder(slide, "SUBTITLE")
+	if placeholder == nil {
+		if DEBUG {
+			logln("The title slide doesn't have a subtitle box, so the subtitle is being left off")
+		}
+		return requests
+	}
+
+	requests = append(requests, &slides.Request{
+		InsertText: &slides.InsertTextRequest{
+			ObjectId: placeholder.ObjectId,
+			Text:     subtitle,
+		},
+	})
+
+	return append(requests, buildDirectionRequests(placeholder.ObjectId)...)
+}
+
+func writeToSlides(outline GPTOutline) (string, string) {
+	validateOutline(outline)
+	checkSlideFit(outline)
+	if options.ReplaceExisting {
+		replaceExistingPresentation(outline.Title)
+	}
+	var writer SlideWriter = liveSlideWriter{getSlidesService()}
+	if options.ImportPptx != "" {
+		writer = &importedSlideWriter{liveSlideWriter: liveSlideWriter{getSlidesService()}, presentationId: importPptx(options.ImportPptx)}
+	}
+	presentationId, url := writeSlides(writer, outline)
+	if folderId := outputFolder(); folderId != "" {
+		moveToFolder(presentationId, folderId)
+	}
+
+	return presentationId, url
+}
+
+// writeSlides builds the whole deck through the given writer, which is the
+// real Slides API except in the self-test
+func writeSlides(writer SlideWriter, outline GPTOutline) (string, string) {
+	logln("Creating your slide show")
+	theme, themed := chosenTheme()
+	var err error
+	// Creating a slideshow will create an empty sldieshow with a single blank
+	// "TITLE" template slide
+	presentation := &slides.Presentation{}
+	presentation.Title = outline.Title
+	pageWidth, pageHeight := pageSize()
+	presentation.PageSize = &slides.Size{Width: emu(pageWidth), Height: emu(pageHeight)}
+	presentation, err = writer.Create(presentation)
+	if err != nil {
+		panic(err)
+	}
+	// Everything gets placed on the page Slides really made
+	usePageSize(presentation.PageSize)
+	// Now we can add the slides we need based off of the outline. I don't know
+	// how to add the content of the slides in the same request as the slide
+	// creation so for now we'll just do it in separate pieces.
+	updates := slides.BatchUpdatePresentationRequest{}
+	updates.Requests = make([]*slides.Request, 0)
+	// Each presentation starts with one slide, so we can skip adding a title
+	// slide and go straight to the content slides. New slides go on the end,
+	// so the agenda has to be made first to land right after the title.
+	// Unless the title slide should have another layout, in which case the
+	// new one goes in at the front and the old one gets deleted, which leaves
+	// everything else where it would have been.
+	if options.TitleLayout != "TITLE" && !options.NoTitleSlide {
+		updates.Requests = append(updates.Requests, &slides.Request{
+			CreateSlide: &slides.CreateSlideRequest{
+				InsertionIndex: 0,
+				SlideLayoutReference: &slides.LayoutReference{
+					PredefinedLayout: options.TitleLayout,
+				},
+				// Zero gets left out of the request otherwise, and that
+				// means the end
+				ForceSendFields: []string{"InsertionIndex"},
+			},
+		})
+		updates.Requests = append(updates.Requests, &slides.Request{
+			DeleteObject: &slides.DeleteObjectRequest{
+				ObjectId: presentation.Slides[0].ObjectId,
+			},
+		})
+	}
+	if options.Agenda {
+		updates.Requests = append(updates.Requests, &slides.Request{
+			CreateSlide: &slides.CreateSlideRequest{
+				SlideLayoutReference: &slides.LayoutReference{
+					PredefinedLayout: options.ContentLayout,
+				},
+			},
+		})
+	}
+	for range outline.Slides {
+		req := slides.Request{
+			CreateSlide: &slides.CreateSlideRequest{
+				SlideLayoutReference: &slides.LayoutReference{
+					PredefinedLayout: options.ContentLayout,
+				},
+			},
+		}
+
+		updates.Requests = append(updates.Requests, &req)
+	}
+	// Add an End Slide to Close Everything Out
+	endReq := slides.Request{
+		CreateSlide: &slides.CreateSlideRequest{
+			SlideLayoutReference: &slides.LayoutReference{
+				PredefinedLayout: options.ClosingLayout,
+			},
+		},
+	}
+	updates.Requests = append(updates.Requests, &endReq)
+	if options.NoTitleSlide {
+		updates.Requests = append(updates.Requests, &slides.Request{
+			DeleteObject: &slides.DeleteObjectRequest{
+				ObjectId: presentation.Slides[0].ObjectId,
+			},
+		})
+	}
+	// Actually submit the updates
+	_, err = writer.BatchUpdate(presentation.PresentationId, &updates)
+	if err != nil {
+		panic(err)
+	}
+	// It's easier to just re-request the presentation to have the up-to-date
+	// data for the slideshow than it is to mess with this weird nesting data
+	// structure. Only the IDs get asked for though, since big decks send back
+	// a lot otherwise.
+	presentation, err = writer.Get(presentation.PresentationId, SLIDE_LAYOUT_FIELDS)
+	if err != nil {
+		panic(err)
+	}
+	// No we can start the process of adding all of the desired content in a
+	// batched update request
+	contentSlidesLength := len(outline.Slides)
+	updates = slides.BatchUpdatePresentationRequest{}
+	updates.Requests = make([]*slides.Request, 0)
+	if themed {
+		updates.Requests = append(updates.Requests, buildColorSchemeRequests(presentation, theme)...)
+	}
+	// The content starts right after the title slide, unless we got rid of
+	// it, in which case it starts at the very beginning
+	var background *slides.PageBackgroundFill
+	if options.BackgroundImage != "" {
+		if imageURL := allowedImage(options.BackgroundImage); imageURL != "" {
+			background = backgroundFill(imageURL)
+		}
+	}
+	firstContentSlide := 0
+	if !options.NoTitleSlide {
+		// Update the title slide
+		updates.Requests = append(updates.Requests, buildTitleSlideRequests(presentation.Slides[0], outline)...)
+		if background != nil && options.BackgroundOnTitle {
+			updates.Requests = append(updates.Requests, buildBackgroundRequest(presentation.Slides[0].ObjectId, background))
+		}
+		firstContentSlide = 1
+	}
+	if options.Agenda {
+		agendaSlide := presentation.Slides[firstContentSlide]
+		targets := presentation.Slides[firstContentSlide+1 : firstContentSlide+1+contentSlidesLength]
+		updates.Requests = append(updates.Requests, buildAgendaRequests(agendaSlide, outline, targets)...)
+		firstContentSlide++
+	}
+	var images []ImageLookup
+	if len(options.imageSources) > 0 {
+		logln("Finding images for your slides")
+		images = findSlideImages(CTX, outline.Slides, imageProviders(options.imageSources), options.ImageConcurrency, options.ImageTimeout)
+	}
+	footer := footerText(time.Now())
+	colors := sectionColors(outline)
+	// The title slide never gets a number, and the closing slide only does
+	// when asked
+	numberedSlides := contentSlidesLength
+	if options.NumberClosingSlide {
+		numberedSlides++
+	}
+	// Update the content slides
+	for i := 1; i <= contentSlidesLength; i++ {
+		slideOutline := outline.Slides[i-1]
+		slide := presentation.Slides[firstContentSlide+i-1]
+		if fitsOnSlide(slideOutline.Table) {
+			// The table takes the place of the bullets
+			slideOutline.Bullets = nil
+			updates.Requests = append(updates.Requests, buildTableRequests(slide, slideOutline.Table)...)
+		} else if len(slideOutline.Table) > 0 {
+			slideOutline = tableAsBullets(slideOutline)
+		}
+		updates.Requests = append(updates.Requests, buildContentTextRequests(slide, slideOutline)...)
+		if colors[i-1] != nil {
+			updates.Requests = append(updates.Requests, buildSectionColorRequests(slide, i, colors[i-1])...)
+		}
+		if slideOutline.Code != "" {
+			updates.Requests = append(updates.Requests, buildCodeRequests(slide, slideOutline.Code, len(slideOutline.Bullets) > 0)...)
+		}
+		// A color from the outline wins over --background-image for its slide
+		if fill := slideBackgroundFill(slideOutline); fill != nil {
+			updates.Requests = append(updates.Requests, buildBackgroundRequest(slide.ObjectId, fill))
+		} else if background != nil {
+			updates.Requests = append(updates.Requests, buildBackgroundRequest(slide.ObjectId, background))
+		}
+		videoId := ""
+		if slideOutline.Video != "" {
+			videoId, err = youTubeId(slideOutline.Video)
+			if err != nil {
+				logf("Leaving the video off \"%s\": %s\n", slideOutline.Title, err)
+			}
+		}
+		// The video takes the image's spot
+		if videoId != "" {
+			updates.Requests = append(updates.Requests, buildVideoRequests(slide.ObjectId, i, videoId)...)
+		} else if images != nil {
+			lookup := images[i-1]
+			if lookup.Err != nil {
+				logf("Could not find an image for \"%s\": %s\n", slideOutline.Title, lookup.Err)
+			} else if lookup.Image.URL != "" {
+				updates.Requests = append(updates.Requests, buildImageRequests(slide, i, lookup.Image, slideOutline.Caption, options.ImageCredit)...)
+			}
+		}
+		if footer != "" {
+			updates.Requests = append(updates.Requests, buildFooterRequests(slide, i, footer)...)
+		}
+		if options.PageNumbers {
+			updates.Requests = append(updates.Requests, buildPageNumberRequests(slide, i, numberedSlides)...)
+		}
+		if slideOutline.Notes != "" {
+			updates.Requests = append(updates.Requests, &slides.Request{
+				InsertText: &slides.InsertTextRequest{
+					ObjectId: slide.SlideProperties.NotesPage.NotesProperties.SpeakerNotesObjectId,
+					Text:     slideOutline.Notes,
+				},
+			})
+		}
+	}
+	// Update End slide
+	closingSlide := presentation.Slides[len(presentation.Slides)-1]
+	closingId, closingRequests := ensureTextBox(closingSlide, "title", titleBox(), "CENTERED_TITLE", "TITLE", "BODY", "SUBTITLE")
+	updates.Requests = append(updates.Requests, closingRequests...)
+	if options.QRSource {
+		if target := qrTarget(outline); target == "" {
+			logln("There's no source URL to point the QR code at, so I'm leaving it off. Try --qr-url.")
+		} else if imageURL, remove, err := qrCodeImage(target); err != nil {
+			logf("Could not make the QR code, so I'm leaving it off: %s\n", err)
+		} else {
+			// Slides copies the picture in during the batch update below
+			defer remove()
+			updates.Requests = append(updates.Requests, buildQRCodeRequests(closingSlide.ObjectId, imageURL)...)
+		}
+	}
+	if options.PageNumbers && options.NumberClosingSlide {
+		updates.Requests = append(updates.Requests, buildPageNumberRequests(closingSlide, numberedSlides, numberedSlides)...)
+	}
+	if options.EmbedOutline {
+		updates.Requests = append(updates.Requests, &slides.Request{
+			InsertText: &slides.InsertTextRequest{
+				ObjectId: closingSlide.SlideProperties.NotesPage.NotesProperties.SpeakerNotesObjectId,
+				Text:     embeddedOutlineNotes(outline),
+			},
+		})
+	}
+	updates.Requests = append(updates.Requests, &slides.Request{
+		InsertText: &slides.InsertTextRequest{
+			ObjectId: closingId,
+			Text:     "The End",
+		},
+	})
+
+	err = batchUpdate(writer, presentation.PresentationId, &updates)
+	if err != nil {
+		panic(err)
+	}
+	if themed && (theme.TitleFont != "" || theme.BodyFont != "") {
+		applyThemeFonts(writer, presentation.PresentationId, theme)
+	}
+
+	url := presentationURL(presentation.PresentationId)
+	logf("Created Presentation: %s\n", url)
+	if options.AutoAdvance {
+		printAutoAdvancePlan(outline, options.WordsPerMinute)
+	}
+	printTransitionNote(options.Transition)
+
+	return presentation.PresentationId, url
+}
+
+func presentationURL(presentationId string) string {
+	return fmt.Sprintf("https://docs.google.com/presentation/d/%s/edit", presentationId)
+}
+
+func buildBaseSlide() *slides.Page {
+	elements := make([]*slides.PageElement, 0)
+	slide := slides.Page{
+		PageType:     "SLIDE",
+		PageElements: elements,
+	}
+
+	return &slide
+}
+
+func getGoogleConfig(scopes []string) *oauth2.Config {
+	credsBytes, err := os.ReadFile(CREDENTIALS_FILE)
+	if err != nil {
+		panic(err)
+	}
+	config, err := google.ConfigFromJSON(credsBytes, scopes...)
+	if err != nil {
+		panic(err)
+	}
+
+	return config
+}
+
+func getGoogleClient() *http.Client {
+	scopes := requiredScopes()
+	tok, granted, err := tokenFromFile(TOKEN_FILE, scopes)
+	if err == errScopeMismatch {
+		logln("The saved Google sign in doesn't cover everything this needs, so you'll have to sign in again")
+	}
+	if err != nil {
+		scopes = mergeScopes(granted, scopes)
+		config := getGoogleConfig(scopes)
+		tok = getTokenFromWeb(config)
+		// The token still works for this run even if it can't be kept
+		if err := saveToken(TOKEN_FILE, tok, scopes); err != nil {
+			logf("%s, so you'll have to sign in again next time\n", err)
+		}
+		return config.Client(googleContext(), tok)
+	}
+	return getGoogleConfig(granted).Client(googleContext(), tok)
+}
+
+func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
+	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
+	logf("Go to the following link in your browser then type the "+
+		"authorization code: \n%v\n", authURL)
+
+	// Scan can't be interrupted, so it waits on its own while Ctrl+C
+	// still gets to stop the sign in
+	scanned := make(chan string, 1)
+	go func() {
+		var input string
+		if _, err := fmt.Scan(&input); err != nil {
+			input = ""
+		}
+		scanned <- input
+	}()
+	var input string
+	select {
+	case input = <-scanned:
+	case <-CTX.Done():
+		fatalf("Stopped before signing in; no token saved.")
+	}
+	if input == "" {
+		fatalf("Unable to read authorization code")
+	}
+	authCode, err := parseAuthCode(input)
+	if err == errAuthDenied {
+		fatalf("Authorization was denied; no token saved.")
+	}
+	if err != nil {
+		fatalf("%s", err)
+	}
+
+	tok, err := config.Exchange(googleContext(), authCode)
+	if err != nil {
+		fatalf("Unable to retrieve token from web: %s", err)
+	}
+
+	return tok
+}
+
+var errAuthDenied = errors.New("authorization was denied")
+
+// parseAuthCode takes whatever got pasted in after signing in. That's usually
+// just the code, but it can be the whole redirect URL, and if the consent
+// screen was cancelled the URL has an error in it instead of a code.
+func parseAuthCode(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if !strings.Contains(input, "=") {
+		return input, nil
+	}
+	rawQuery := input
+	if _, after, found := strings.Cut(input, "?"); found {
+		rawQuery = after
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", fmt.Errorf("could not make sense of \"%s\" as an authorization code", input)
+	}
+	switch query.Get("error") {
+	case "":
+	case "access_denied":
+		return "", errAuthDenied
+	default:
+		return "", fmt.Errorf("Google sign in failed: %s", query.Get("error"))
+	}
+	if query.Get("code") == "" {
+		return "", fmt.Errorf("there's no authorization code in \"%s\"", input)
+	}
+
+	return query.Get("code"), nil
+}
+
+// cachedToken is what goes in token.json. Along with the token itself we keep
+// the scopes it was granted, so we can tell when it isn't enough anymore.
+type cachedToken struct {
+	*oauth2.Token
+	Scopes []string `json:"scopes"`
+}
+
+// tokenFromFile loads the cached token, handing back errScopeMismatch if it
+// wasn't granted all of scopes. The granted scopes come back either way.
+func tokenFromFile(file string, scopes []string) (*oauth2.Token, []string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	cached := &cachedToken{}
+	err = json.NewDecoder(f).Decode(cached)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Tokens saved before we kept track of scopes don't have any listed, so
+	// those get redone once as well
+	if !hasScopes(cached.Scopes, scopes) {
+		return nil, cached.Scopes, errScopeMismatch
+	}
+
+	return cached.Token, cached.Scopes, nil
+}
+
+// saveToken writes the token to a temp file next to the real one and then
+// swaps it in, so getting interrupted partway can't leave a broken token
+// behind for the next run to trip over
+func saveToken(path string, token *oauth2.Token, scopes []string) error {
+	logf("Saving credential file to: %s\n", path)
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("could not cache the OAuth token: %w", err)
+	}
+	// Once it's been renamed there's nothing left here to remove
+	defer os.Remove(f.Name())
+	err = json.NewEncoder(f).Encode(cachedToken{Token: token, Scopes: scopes})
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("could not cache the OAuth token: %w", err)
+	}
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("could not cache the OAuth token: %w", err)
+	}
+	err = os.Rename(f.Name(), path)
+	if err != nil {
+		return fmt.Errorf("could not cache the OAuth token: %w", err)
+	}
+
+	return nil
+}
+
+// runSelfTest pushes the bundled example outline through the parser so you can
+// check things work without spending any OpenAI credits. Only when live is set
+// does it go on to actually build a deck.
+func runSelfTest(live bool) {
+	f, err := os.ReadFile(EXAMPLE_OUTLINE_FILE)
+	if err != nil {
+		logln("Could not read the example outline")
+		panic(err)
+	}
+	p := parseGPTOutline(string(f))
+	if len(p.Slides) == 0 {
+		giveUpOnGarbage(string(f))
+	}
+
+	// This checks what the parser made of it, before the clean up passes
+	// get a chance to paper over anything missing
+	problems := 0
+	for i, slide := range p.Slides {
+		logf("  %d. %s (%d bullets)\n", i+1, slide.Title, len(slide.Bullets))
+		if slide.Title == UNNAMED_TITLE || (len(slide.Bullets) == 0 && slide.Code == "") {
+			logf("     slide %d is missing a title or bullets\n", i+1)
+			problems++
+		}
+	}
+	if problems > 0 {
+		fatalf("Self-test failed")
+	}
+	logf("Self-test parsed %d slides\n", len(p.Slides))
+	p = postProcessOutline(p)
+	p.Title = "Doctor Slides Test — " + deckTimestamp(time.Now())
+
+	if live {
+		_, url := writeToSlides(p)
+		if options.PrintURLOnly {
+			fmt.Println(url)
+		}
+		return
+	}
+	checkFakeDeck(p)
+}
+
+// checkFakeDeck builds the self-test deck against the fake Slides API and
+// makes sure the requests it sent add up to the outline. Anything that would
+// reach out to the internet for it is switched off first.
+func checkFakeDeck(outline GPTOutline) {
+	options.ImageSource = "none"
+	options.imageSources = nil
+	options.BackgroundImage = ""
+	options.QRSource = false
+	writer := newFakeSlideWriter()
+	writeSlides(writer, outline)
+
+	problems := 0
+	wantSlides := len(outline.Slides) + 1
+	if options.Agenda {
+		wantSlides++
+	}
+	if options.TitleLayout != "TITLE" && !options.NoTitleSlide {
+		wantSlides++
+	}
+	if created := writer.createdSlides(); created != wantSlides {
+		logf("  expected %d new slides but %d were made\n", wantSlides, created)
+		problems++
+	}
+	// The titles have to go in in order: the deck title, then each content
+	// slide, then the closing slide
+	want := make([]string, 0)
+	if !options.NoTitleSlide {
+		want = append(want, outline.Title)
+	}
+	for _, slide := range outline.Slides {
+		want = append(want, slide.Title)
+	}
+	want = append(want, "The End")
+	texts := writer.insertedText()
+	next := 0
+	for _, text := range texts {
+		if next < len(want) && text == want[next] {
+			next++
+		}
+	}
+	if next < len(want) {
+		logf("  \"%s\" never made it into the deck where it should have\n", want[next])
+		problems++
+	}
+	if len(texts) > 0 && texts[len(texts)-1] != "The End" {
+		logf("  the closing slide text wasn't the last thing written\n")
+		problems++
+	}
+	if problems > 0 {
+		fatalf("Self-test failed")
+	}
+	logf("Self-test sent %d requests for %d slides to the fake deck\n", len(writer.Requests), len(writer.presentation.Slides))
+}
@@ -0,0 +1,144 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/docs/v1"
+	"io"
+	"os"
+	"testing"
+)
+
+// useDefaultOptions puts the options back to what the command line starts
+// with for the length of a test, since nearly everything reads them. The
+// progress messages are kept out of the test output too.
+func useDefaultOptions(t testing.TB) {
+	t.Helper()
+	saved, savedLog := options, LOG
+	options = DefaultOptions()
+	LOG = io.Discard
+	t.Cleanup(func() { options, LOG = saved, savedLog })
+}
+
+func TestExampleOutlineParses(t *testing.T) {
+	useDefaultOptions(t)
+	content, err := os.ReadFile("../" + EXAMPLE_OUTLINE_FILE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outline := parseGPTOutline(string(content))
+	if len(outline.Slides) != 3 {
+		t.Fatalf("parsed %d slides from the example outline, want 3", len(outline.Slides))
+	}
+	for i, slide := range outline.Slides {
+		if slide.Title == UNNAMED_TITLE || len(slide.Bullets) == 0 {
+			t.Errorf("slide %d is missing a title or bullets: %+v", i+1, slide)
+		}
+	}
+}
+
+func TestParseNestedBullets(t *testing.T) {
+	useDefaultOptions(t)
+	outline := parseGPTOutline(`NEW SLIDE ======
+Title: Nesting
+- Top level
+  - Two spaces in
+	- A tab in
+- Back to the top
+    - Four spaces in
+END SLIDE ======
+`)
+	if len(outline.Slides) != 1 {
+		t.Fatalf("parsed %d slides, want 1", len(outline.Slides))
+	}
+	want := []Bullet{
+		{Text: "Top level", Level: 0},
+		{Text: "Two spaces in", Level: 1},
+		{Text: "A tab in", Level: 1},
+		{Text: "Back to the top", Level: 0},
+		{Text: "Four spaces in", Level: 2},
+	}
+	got := outline.Slides[0].Bullets
+	if len(got) != len(want) {
+		t.Fatalf("parsed %d bullets, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Text != want[i].Text || got[i].Level != want[i].Level {
+			t.Errorf("bullet %d = %q at level %d, want %q at level %d", i, got[i].Text, got[i].Level, want[i].Text, want[i].Level)
+		}
+	}
+}
+
+func TestEmptyDocumentError(t *testing.T) {
+	useDefaultOptions(t)
+	tests := []struct {
+		name     string
+		document *docs.Document
+		want     string
+	}{
+		{
+			name:     "no paragraphs",
+			document: &docs.Document{Body: &docs.Body{}},
+			want:     "The document appears to have no readable text.",
+		},
+		{
+			name: "only images",
+			document: &docs.Document{
+				Body: &docs.Body{
+					Content: []*docs.StructuralElement{
+						{Paragraph: &docs.Paragraph{Elements: []*docs.ParagraphElement{
+							{InlineObjectElement: &docs.InlineObjectElement{InlineObjectId: "image_1"}},
+						}}},
+					},
+				},
+				InlineObjects: map[string]docs.InlineObject{"image_1": {ObjectId: "image_1"}},
+			},
+			want: "The document appears to have no readable text. It looks like it's only images, and I can only work with text.",
+		},
+		{
+			name:     "some text",
+			document: &docs.Document{Body: &docs.Body{Content: []*docs.StructuralElement{textParagraph("Hello\n")}}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := emptyDocumentError(test.document, readTextFromDocument(test.document))
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != test.want {
+				t.Errorf("emptyDocumentError() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// largeDocument is a made up doc about the size of a long report, with a
+// heading every few paragraphs and the odd table
+func largeDocument(paragraphs int) *docs.Document {
+	content := make([]*docs.StructuralElement, 0, paragraphs)
+	for i := 0; i < paragraphs; i++ {
+		switch {
+		case i%50 == 0:
+			content = append(content, tableDocument([][]string{{"Plan", "Price"}, {"Basic", "$5"}}).Body.Content[1])
+		case i%10 == 0:
+			heading := textParagraph(fmt.Sprintf("Section %d\n", i/10))
+			heading.Paragraph.ParagraphStyle = &docs.ParagraphStyle{NamedStyleType: "HEADING_1"}
+			content = append(content, heading)
+		default:
+			content = append(content, textParagraph(fmt.Sprintf("Paragraph %d goes on for a little while about nothing much at all.\n", i)))
+		}
+	}
+
+	return &docs.Document{Title: "Large", Body: &docs.Body{Content: content}}
+}
+
+func BenchmarkReadTextFromDocument(b *testing.B) {
+	useDefaultOptions(b)
+	document := largeDocument(20000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		readTextFromDocument(document)
+	}
+}
@@ -0,0 +1,448 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/slides/v1"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// MARKDOWN_EXTENSIONS are the files that get read as Markdown instead of
+// being taken for a document ID
+var MARKDOWN_EXTENSIONS = []string{".md", ".markdown"}
+
+var MARKDOWN_IMAGE = regexp.MustCompile(`!\[([^\]]*)\]\(\s*(\S+?)(?:\s+"[^"]*")?\s*\)`)
+var MARKDOWN_BULLET = regexp.MustCompile(`^([-*+]|\d+[.)])\s+`)
+var MARKDOWN_TABLE_SEPARATOR = regexp.MustCompile(`^:?-+:?$`)
+
+// Emphasis is a styled run of a bullet's text. Start and End count runes,
+// not bytes, so they survive the text being turned into UTF-16 for Slides.
+type Emphasis struct {
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+	Bold   bool   `json:"bold,omitempty"`
+	Italic bool   `json:"italic,omitempty"`
+	Code   bool   `json:"code,omitempty"`
+	Link   string `json:"link,omitempty"`
+	// Strikethrough is only ever set for checked off checklist items
+	Strikethrough bool `json:"strikethrough,omitempty"`
+}
+
+func isMarkdownFile(positional []string) bool {
+	if len(positional) == 0 {
+		return false
+	}
+
+	return isOneOf(strings.ToLower(filepath.Ext(positional[0])), MARKDOWN_EXTENSIONS)
+}
+
+// buildOutlineFromMarkdown reads the outline straight out of a Markdown file,
+// since the headings and bullets already say what goes on each slide. GPT
+// only gets asked anything with --refine or --notes-lang.
+func buildOutlineFromMarkdown(path string) GPTOutline {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fatalf("Could not read %s: %s", path, err)
+	}
+	outline := parseMarkdown(string(content))
+	if len(outline.Slides) == 0 {
+		fatalf("There aren't any \"## \" headings in %s to make slides out of.", path)
+	}
+	if outline.Title == "" {
+		outline.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if options.Refine {
+		requireOpenAIKey()
+		outline = mergeRefinedTitle(outline, refineOutline(string(content), formatOutline(outline), outline))
+	} else if options.NotesLang != "" {
+		requireOpenAIKey()
+		outline = translateNotes(outline)
+	}
+
+	return postProcessOutline(stripOutlineEmphasis(outline))
+}
+
+// mergeRefinedTitle keeps the file's title, since GPT's outline format
+// doesn't have one
+func mergeRefinedTitle(original GPTOutline, refined GPTOutline) GPTOutline {
+	refined.Title = original.Title
+
+	return refined
+}
+
+// parseMarkdown turns the first "# " heading into the deck title, every
+// "## " heading into a slide, and list items into bullets. The emphasis
+// markers are left in the bullets for stripOutlineEmphasis, so GPT still
+// sees them if it's refining.
+func parseMarkdown(text string) GPTOutline {
+	outline := GPTOutline{Slides: make([]SimpleSlide, 0)}
+	var current *SimpleSlide
+	// A # heading after the title starts a section that the ## slides
+	// under it belong to
+	section := ""
+	inCode := false
+	codeIndent := ""
+	codeLines := make([]string, 0)
+	finishSlide := func() {
+		if current == nil {
+			return
+		}
+		if current.Table != nil {
+			current.Table = normalizeTable(current.Table)
+		}
+		outline.Slides = append(outline.Slides, *current)
+		current = nil
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		cleanLine := strings.TrimSpace(line)
+		if strings.HasPrefix(cleanLine, CODE_FENCE) {
+			if inCode {
+				if current != nil {
+					if current.Code != "" {
+						current.Code = current.Code + "\n\n"
+					}
+					current.Code = current.Code + strings.Join(codeLines, "\n")
+				}
+				inCode = false
+				codeLines = make([]string, 0)
+			} else {
+				inCode = true
+				codeIndent = fenceIndent(line)
+			}
+			continue
+		}
+		if inCode {
+			codeLines = append(codeLines, codeLine(line, codeIndent))
+			continue
+		}
+		if cleanLine == "" {
+			continue
+		}
+		if level, heading := markdownHeading(cleanLine); level > 0 {
+			switch {
+			case level == 1 && outline.Title == "" && current == nil && len(outline.Slides) == 0:
+				outline.Title, _ = parseEmphasis(heading)
+			case level <= 2:
+				finishSlide()
+				title, _ := parseEmphasis(heading)
+				if level == 1 {
+					section = title
+				}
+				current = &SimpleSlide{Title: title, Bullets: make([]Bullet, 0), Section: section}
+			case current != nil:
+				// Anything deeper than a slide is a bold bullet
+				current.Bullets = append(current.Bullets, Bullet{Text: "**" + heading + "**"})
+			}
+			continue
+		}
+		// An image on a line with nothing else becomes the slide's image
+		if match := MARKDOWN_IMAGE.FindStringSubmatch(cleanLine); match != nil && current != nil {
+			if current.Image == "" {
+				current.Image = match[2]
+				if current.Caption == "" {
+					current.Caption = match[1]
+				}
+			}
+			cleanLine = strings.TrimSpace(MARKDOWN_IMAGE.ReplaceAllString(cleanLine, ""))
+			if cleanLine == "" {
+				continue
+			}
+		}
+		if current == nil {
+			// Whatever is between the title and the first slide is the subtitle
+			if outline.Subtitle == "" {
+				outline.Subtitle, _ = parseEmphasis(cleanLine)
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(cleanLine, ">"):
+			notes, _ := parseEmphasis(strings.TrimSpace(strings.TrimLeft(cleanLine, "> ")))
+			current.Notes = strings.TrimSpace(current.Notes + "\n" + notes)
+		case strings.HasPrefix(cleanLine, "|"):
+			row := parseTableRow(strings.Trim(cleanLine, "|"))
+			if !isTableSeparatorRow(row) {
+				current.Table = append(current.Table, row)
+			}
+		case MARKDOWN_BULLET.MatchString(cleanLine):
+			current.Bullets = append(current.Bullets, checklistBullet(MARKDOWN_BULLET.ReplaceAllString(cleanLine, ""), indentLevel(line)))
+		default:
+			// Plain paragraphs go on the slide as top level bullets
+			current.Bullets = append(current.Bullets, Bullet{Text: cleanLine})
+		}
+	}
+	finishSlide()
+
+	return outline
+}
+
+func markdownHeading(line string) (int, string) {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level >= len(line) || line[level] != ' ' {
+		return 0, ""
+	}
+
+	return level, strings.TrimSpace(strings.TrimRight(line[level:], "#"))
+}
+
+func isTableSeparatorRow(row []string) bool {
+	for _, cell := range row {
+		if !MARKDOWN_TABLE_SEPARATOR.MatchString(cell) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// formatOutline writes the outline back out the way GPT is asked to, for
+// --refine to have something to work from
+func formatOutline(outline GPTOutline) string {
+	format := OUTLINE_FORMAT
+	var b strings.Builder
+	if outline.Subtitle != "" {
+		fmt.Fprintf(&b, "%s%s\n\n", format.Subtitle, outline.Subtitle)
+	}
+	for _, slide := range outline.Slides {
+		fmt.Fprintf(&b, "%s\n%s%s\n", format.SlideStart, format.Title, slide.Title)
+		for _, bullet := range slide.Bullets {
+			fmt.Fprintf(&b, "%s%s%s%s\n", strings.Repeat("  ", bullet.Level), format.Bullet, checkboxMarker(bullet), bullet.Text)
+		}
+		if slide.Image != "" {
+			fmt.Fprintf(&b, "%s%s\n", format.ImageURL, slide.Image)
+		}
+		if slide.Caption != "" {
+			fmt.Fprintf(&b, "%s%s\n", format.Caption, slide.Caption)
+		}
+		for _, row := range slide.Table {
+			fmt.Fprintf(&b, "%s%s\n", format.Table, strings.Join(row, " | "))
+		}
+		if slide.Code != "" {
+			fmt.Fprintf(&b, "%s\n%s\n%s\n", CODE_FENCE, slide.Code, CODE_FENCE)
+		}
+		if slide.Notes != "" {
+			fmt.Fprintf(&b, "%s%s\n", format.Notes, strings.ReplaceAll(slide.Notes, "\n", " "))
+		}
+		if slide.Section != "" {
+			fmt.Fprintf(&b, "%s%s\n", format.Section, slide.Section)
+		}
+		if slide.Background != "" {
+			fmt.Fprintf(&b, "%s%s\n", format.Background, slide.Background)
+		}
+		fmt.Fprintf(&b, "%s\n\n", format.SlideEnd)
+	}
+
+	return b.String()
+}
+
+// stripOutlineEmphasis takes the Markdown markers out of every bullet and
+// keeps where they were as Emphasis runs instead
+func stripOutlineEmphasis(outline GPTOutline) GPTOutline {
+	for i := range outline.Slides {
+		for j, bullet := range outline.Slides[i].Bullets {
+			outline.Slides[i].Bullets[j].Text, outline.Slides[i].Bullets[j].Emphasis = parseEmphasis(bullet.Text)
+		}
+	}
+
+	return outline
+}
+
+// parseEmphasis reads **bold**, *italic*, `code` and [links](url) out of a
+// line of Markdown, giving back the plain text and where each style goes
+func parseEmphasis(text string) (string, []Emphasis) {
+	plain, emphasis := parseInline([]rune(text))
+
+	return string(plain), emphasis
+}
+
+func parseInline(in []rune) ([]rune, []Emphasis) {
+	out := make([]rune, 0, len(in))
+	emphasis := make([]Emphasis, 0)
+	// Styles found inside a run get shifted to where the run landed
+	addRun := func(content []rune, style Emphasis) {
+		inner, innerEmphasis := parseInline(content)
+		if style.Code {
+			inner, innerEmphasis = content, nil
+		}
+		style.Start = len(out)
+		out = append(out, inner...)
+		style.End = len(out)
+		emphasis = append(emphasis, style)
+		for _, e := range innerEmphasis {
+			e.Start += style.Start
+			e.End += style.Start
+			emphasis = append(emphasis, e)
+		}
+	}
+	for i := 0; i < len(in); {
+		switch in[i] {
+		case '`':
+			if j := indexRuneFrom(in, '`', i+1); j > i+1 {
+				addRun(in[i+1:j], Emphasis{Code: true})
+				i = j + 1
+				continue
+			}
+		case '[':
+			if end := indexRuneFrom(in, ']', i+1); end > i+1 && end+1 < len(in) && in[end+1] == '(' {
+				if close := indexRuneFrom(in, ')', end+2); close > end+2 {
+					addRun(in[i+1:end], Emphasis{Link: strings.TrimSpace(string(in[end+2 : close]))})
+					i = close + 1
+					continue
+				}
+			}
+		case '*', '_':
+			double := i+1 < len(in) && in[i+1] == in[i]
+			width := 1
+			if double {
+				width = 2
+			}
+			if j := closingMarker(in, i, width); j >= 0 {
+				addRun(in[i+width:j], Emphasis{Bold: double, Italic: !double})
+				i = j + width
+				continue
+			}
+		}
+		out = append(out, in[i])
+		i++
+	}
+
+	return out, emphasis
+}
+
+// closingMarker finds the end of the * or _ run that opens at start. The
+// underscore only counts at the edges of words, so snake_case is left alone.
+func closingMarker(in []rune, start int, width int) int {
+	marker := in[start]
+	from := start + width
+	if from >= len(in) || unicode.IsSpace(in[from]) {
+		return -1
+	}
+	if marker == '_' && start > 0 && isWordRune(in[start-1]) {
+		return -1
+	}
+	for k := from; k < len(in); {
+		if in[k] != marker {
+			k++
+			continue
+		}
+		run := 0
+		for k+run < len(in) && in[k+run] == marker {
+			run++
+		}
+		end := k + run
+		if k > from && !unicode.IsSpace(in[k-1]) && !(marker == '_' && end < len(in) && isWordRune(in[end])) {
+			if width == 2 && run >= 2 {
+				return end - 2
+			}
+			if width == 1 && run%2 == 1 {
+				return end - 1
+			}
+		}
+		k = end
+	}
+
+	return -1
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func indexRuneFrom(in []rune, r rune, from int) int {
+	for i := from; i < len(in); i++ {
+		if in[i] == r {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// buildEmphasisRequests styles the runs of each bullet in the body text. It
+// has to go in before the bullets are applied, while the leading tabs are
+// still part of the text. prefix is the custom glyph, if there is one.
+func buildEmphasisRequests(bodyId string, bullets []Bullet, prefix string) []*slides.Request {
+	requests := make([]*slides.Request, 0)
+	offset := 0
+	for _, bullet := range bullets {
+		lead := utf16Length(strings.Repeat("\t", bullet.Level) + prefix)
+		runes := []rune(bullet.Text)
+		emphasis := bullet.Emphasis
+		if bullet.Checked {
+			// The checkbox preset can't be ticked, so checked items get
+			// crossed out instead
+			emphasis = append(emphasis[:len(emphasis):len(emphasis)], Emphasis{Start: 0, End: len(runes), Strikethrough: true})
+		}
+		for _, e := range emphasis {
+			// Anything that got cut off by truncating doesn't get styled
+			if e.Start < 0 || e.End > len(runes) || e.Start >= e.End {
+				continue
+			}
+			start := int64(offset + lead + utf16Length(string(runes[:e.Start])))
+			end := start + int64(utf16Length(string(runes[e.Start:e.End])))
+			style, fields := emphasisStyle(e)
+			requests = append(requests, &slides.Request{
+				UpdateTextStyle: &slides.UpdateTextStyleRequest{
+					ObjectId: bodyId,
+					TextRange: &slides.Range{
+						Type:       "FIXED_RANGE",
+						StartIndex: &start,
+						EndIndex:   &end,
+					},
+					Style:  style,
+					Fields: fields,
+				},
+			})
+		}
+		// Plus one for the newline between lines
+		offset += lead + utf16Length(bullet.Text) + 1
+	}
+
+	return requests
+}
+
+func emphasisStyle(e Emphasis) (*slides.TextStyle, string) {
+	style := &slides.TextStyle{}
+	fields := make([]string, 0)
+	if e.Bold {
+		style.Bold = true
+		fields = append(fields, "bold")
+	}
+	if e.Italic {
+		style.Italic = true
+		fields = append(fields, "italic")
+	}
+	if e.Code {
+		style.FontFamily = CODE_FONT
+		fields = append(fields, "fontFamily")
+	}
+	if e.Link != "" {
+		style.Link = &slides.Link{Url: e.Link}
+		fields = append(fields, "link")
+	}
+	if e.Strikethrough {
+		style.Strikethrough = true
+		fields = append(fields, "strikethrough")
+	}
+
+	return style, strings.Join(fields, ",")
+}
+
+// shiftEmphasis moves the runs along when something gets put in front of
+// the text
+func shiftEmphasis(emphasis []Emphasis, by int) []Emphasis {
+	shifted := make([]Emphasis, 0, len(emphasis))
+	for _, e := range emphasis {
+		e.Start += by
+		e.End += by
+		shifted = append(shifted, e)
+	}
+
+	return shifted
+}
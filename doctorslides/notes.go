@@ -0,0 +1,213 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/slides/v1"
+	"strings"
+	"unicode"
+)
+
+// notesFromBullets fills in speaker notes for any slide that doesn't already
+// have them. Normally that's just the bullets, but with expand each bullet
+// gets turned into a full sentence by GPT, which costs a call per slide.
+func notesFromBullets(outline GPTOutline, expand bool) GPTOutline {
+	if expand {
+		logln("Asking GPT to expand the bullets into speaker notes")
+	}
+	// The bullets are in the slides' language, so the notes made from them
+	// need translating if they go in another one
+	fromBullets := make([]int, 0)
+	for i := range outline.Slides {
+		slide := &outline.Slides[i]
+		if slide.Notes != "" || len(slide.Bullets) == 0 {
+			continue
+		}
+		bullets := strings.Join(bulletTexts(slide.Bullets), "\n")
+		slide.Notes = bullets
+		if !expand {
+			fromBullets = append(fromBullets, i)
+			continue
+		}
+		expanded, err := expandBulletsIntoNotes(slide.Title, bullets)
+		if err != nil {
+			logf("Could not expand the notes for \"%s\", using the bullets instead: %s\n", slide.Title, err)
+			continue
+		}
+		slide.Notes = expanded
+	}
+	if len(fromBullets) > 0 && notesInOtherLanguage() {
+		logf("Asking GPT to translate the speaker notes into %s\n", languageName(options.NotesLang))
+		for _, i := range fromBullets {
+			translateSlideNotes(&outline.Slides[i])
+		}
+	}
+
+	return outline
+}
+
+func expandBulletsIntoNotes(title string, bullets string) (string, error) {
+	template := `
+	These are the bullet points from a presentation slide titled "%s". Turn
+	each bullet point into one full sentence the presenter could say out loud.
+	Put each sentence on its own line and don't add anything else.%s
+
+	%s`
+	extra := ""
+	if notesLanguage() != "" {
+		extra = fmt.Sprintf(" Write the sentences in %s.", languageName(notesLanguage()))
+	}
+	if options.NotesWords > 0 {
+		extra += fmt.Sprintf(" Use about %d words in all.", options.NotesWords)
+	}
+
+	return askGPT(fmt.Sprintf(template, title, extra, bullets))
+}
+
+// NOTES_WORDS_SLACK is how far over --notes-words the notes can run before
+// they get cut back, since GPT never lands right on the number
+const NOTES_WORDS_SLACK = 1.5
+
+func notesWordsInstruction() string {
+	if options.NotesWords <= 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" Give each slide about %d words of speaker notes, enough to say in the time the slide is up.", options.NotesWords)
+}
+
+// capOutlineNotes cuts notes that ran well past --notes-words back to the
+// last sentence that fits. The first sentence always stays, however long it
+// is, so there's never a sentence cut off halfway.
+func capOutlineNotes(outline GPTOutline, words int) GPTOutline {
+	limit := int(float64(words) * NOTES_WORDS_SLACK)
+	for i := range outline.Slides {
+		slide := &outline.Slides[i]
+		if len(strings.Fields(slide.Notes)) <= limit {
+			continue
+		}
+		kept := make([]string, 0)
+		count := 0
+		for _, sentence := range splitSentences(slide.Notes) {
+			sentenceWords := len(strings.Fields(sentence))
+			if len(kept) > 0 && count+sentenceWords > limit {
+				break
+			}
+			kept = append(kept, sentence)
+			count += sentenceWords
+		}
+		if DEBUG {
+			logf("Cut the notes for \"%s\" down to %d words\n", slide.Title, count)
+		}
+		slide.Notes = strings.TrimSpace(strings.Join(kept, ""))
+	}
+
+	return outline
+}
+
+// splitSentences breaks text up after each full stop, question mark, or
+// exclamation mark, keeping the spacing with the sentence it follows so
+// the pieces join back up the same
+func splitSentences(text string) []string {
+	sentences := make([]string, 0)
+	runes := []rune(text)
+	start := 0
+	for i, r := range runes {
+		if !strings.ContainsRune(".!?。！？", r) {
+			continue
+		}
+		end := i + 1
+		if end < len(runes) && !unicode.IsSpace(runes[end]) {
+			continue
+		}
+		for end < len(runes) && unicode.IsSpace(runes[end]) {
+			end++
+		}
+		sentences = append(sentences, string(runes[start:end]))
+		start = end
+	}
+	if start < len(runes) {
+		sentences = append(sentences, string(runes[start:]))
+	}
+
+	return sentences
+}
+
+// EXISTING_NOTES are the choices for --existing-notes, which decides what
+// happens to the notes already on a slide when --slide-range regenerates it
+var EXISTING_NOTES = []string{"keep", "merge", "replace"}
+
+// speakerNotes finds the speaker notes box on a slide's notes page, if the
+// slide has one
+func speakerNotes(slide *slides.Page) *slides.PageElement {
+	if slide.SlideProperties == nil || slide.SlideProperties.NotesPage == nil {
+		return nil
+	}
+	notesPage := slide.SlideProperties.NotesPage
+	if notesPage.NotesProperties == nil {
+		return nil
+	}
+	for _, element := range notesPage.PageElements {
+		if element.ObjectId == notesPage.NotesProperties.SpeakerNotesObjectId {
+			return element
+		}
+	}
+
+	return nil
+}
+
+// shapeText pulls the plain text back out of a shape
+func shapeText(element *slides.PageElement) string {
+	if element == nil || element.Shape == nil || element.Shape.Text == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, textElement := range element.Shape.Text.TextElements {
+		if textElement.TextRun != nil {
+			b.WriteString(textElement.TextRun.Content)
+		}
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// mergeNotes puts the new notes after the old ones, unless the old ones
+// already have them from an earlier run
+func mergeNotes(existing string, notes string) string {
+	if existing == "" {
+		return notes
+	}
+	if notes == "" || strings.Contains(existing, notes) {
+		return existing
+	}
+
+	return existing + "\n\n" + notes
+}
+
+// buildExistingNotesRequests rewrites the notes on a slide being updated.
+// keep leaves them alone like updates always have, merge adds the new notes
+// to whatever the presenter already wrote, and replace swaps them out.
+func buildExistingNotesRequests(slide *slides.Page, notes string, mode string) []*slides.Request {
+	element := speakerNotes(slide)
+	if mode == "keep" || element == nil {
+		return nil
+	}
+	text := notes
+	if mode == "merge" {
+		existing := shapeText(element)
+		text = mergeNotes(existing, notes)
+		if text == existing {
+			return nil
+		}
+	}
+	requests := buildClearTextRequests(element)
+	if text != "" {
+		requests = append(requests, &slides.Request{
+			InsertText: &slides.InsertTextRequest{
+				ObjectId: element.ObjectId,
+				Text:     text,
+			},
+		})
+	}
+
+	return requests
+}
@@ -0,0 +1,133 @@
+package doctorslides
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/microsoft"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// --target onedrive is experimental. Graph can't build a PowerPoint out of
+// slides the way the Slides API can, so the pptx gets put together here from
+// the outline (see pptx.go) and Graph only has to store it. Nothing about
+// the deck goes through Google, only the doc it's made from.
+
+const MS_TOKEN_FILE = "ms_token.json"
+const GRAPH_API = "https://graph.microsoft.com/v1.0"
+
+// MS_SCOPES only ask for the user's own files, plus a refresh token so the
+// sign in lasts past the hour
+var MS_SCOPES = []string{"Files.ReadWrite", "offline_access"}
+
+var TARGETS = []string{"slides", "onedrive"}
+
+// ONEDRIVE_UNSUPPORTED_FLAGS need the deck to be in Google Slides, or put
+// things in Google Drive, so they're turned down with --target onedrive
+var ONEDRIVE_UNSUPPORTED_FLAGS = []string{
+	"thumbnails",
+	"speaker-doc",
+	"import-pptx",
+	"replace-existing",
+	"output-folder",
+	"shared-drive",
+	"theme-from",
+	"title-layout",
+	"content-layout",
+	"closing-layout",
+}
+
+func getMicrosoftConfig() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     MS_CLIENT_ID,
+		ClientSecret: MS_CLIENT_SECRET,
+		Endpoint:     microsoft.AzureADEndpoint(MS_TENANT),
+		RedirectURL:  MS_REDIRECT_URL,
+		Scopes:       MS_SCOPES,
+	}
+}
+
+// getMicrosoftClient signs in to Microsoft the same way getGoogleClient does
+// for Google, pasting the code back from the browser, but with its own app
+// registration and token file so the two never get mixed up
+func getMicrosoftClient() *http.Client {
+	config := getMicrosoftConfig()
+	tok, _, err := tokenFromFile(MS_TOKEN_FILE, MS_SCOPES)
+	if err != nil {
+		logln("Signing in to Microsoft for --target onedrive")
+		tok = getTokenFromWeb(config)
+		if err := saveToken(MS_TOKEN_FILE, tok, MS_SCOPES); err != nil {
+			logf("%s, so you'll have to sign in again next time\n", err)
+		}
+	}
+
+	return config.Client(googleContext(), tok)
+}
+
+// oneDrivePath is where in OneDrive the deck goes, with each piece escaped
+// for the Graph URL
+func oneDrivePath(folder string, name string) string {
+	parts := make([]string, 0)
+	for _, part := range strings.Split(strings.Trim(folder, "/"), "/") {
+		if part != "" {
+			parts = append(parts, url.PathEscape(part))
+		}
+	}
+	parts = append(parts, url.PathEscape(safeFileName(name)+".pptx"))
+
+	return strings.Join(parts, "/")
+}
+
+// uploadToOneDrive puts the pptx in the user's OneDrive, replacing any file
+// that's already at that path, and hands back its web link. Graph's simple
+// upload tops out at 250MB, which no deck of ours comes close to.
+func uploadToOneDrive(content []byte, folder string, name string) (string, error) {
+	endpoint := fmt.Sprintf("%s/me/drive/root:/%s:/content", GRAPH_API, oneDrivePath(folder, name))
+	req, err := http.NewRequestWithContext(CTX, http.MethodPut, endpoint, bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", PPTX_MIME_TYPE)
+	resp, err := getMicrosoftClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("OneDrive returned %s", resp.Status)
+	}
+	var item struct {
+		WebURL string `json:"webUrl"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&item)
+	if err != nil {
+		return "", err
+	}
+
+	return item.WebURL, nil
+}
+
+// writeToOneDrive builds the outline into a pptx and uploads it to the
+// user's OneDrive, handing back its web link
+func writeToOneDrive(outline GPTOutline) string {
+	validateOutline(outline)
+	logln("Creating your slide show as a pptx")
+	logPptxLeftOff(outline, "--target onedrive")
+	content, err := buildPptx(outline)
+	if err != nil {
+		logln("Could not build the pptx")
+		panic(err)
+	}
+	logln("Uploading the presentation to OneDrive")
+	webURL, err := uploadToOneDrive(content, options.OneDriveFolder, outline.Title)
+	if err != nil {
+		logln("Could not upload the presentation to OneDrive")
+		panic(err)
+	}
+	logf("Uploaded to OneDrive: %s\n", webURL)
+
+	return webURL
+}
@@ -0,0 +1,58 @@
+package doctorslides
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// Drive's appProperties would be the tidier place for the outline, but they
+// only hold 124 bytes a property. The closing slide's speaker notes hold as
+// much as any text box does, which is plenty for an outline, just not
+// something to read through in presenter view.
+
+// embeddedOutlineNotes is the outline exactly like --write-outline saves
+// it, so the closing slide's notes can be pasted into a file and used with
+// --from-outline to rebuild or diff the deck
+func embeddedOutlineNotes(outline GPTOutline) string {
+	encoded, err := json.MarshalIndent(outline, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+
+	return string(encoded)
+}
+
+// writeOutlineFile saves an outline as JSON so it can be looked over and
+// edited by hand before it gets turned into a deck with --from-outline
+func writeOutlineFile(outline GPTOutline, path string) {
+	encoded, err := json.MarshalIndent(outline, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	err = os.WriteFile(path, append(encoded, '\n'), 0644)
+	if err != nil {
+		logln("Could not write the outline")
+		panic(err)
+	}
+	logf("Saved the outline to: %s\n", path)
+}
+
+func readOutlineFile(path string) GPTOutline {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		logln("Could not read the outline")
+		panic(err)
+	}
+	outline := GPTOutline{}
+	err = json.Unmarshal(content, &outline)
+	if err != nil {
+		fatalf("%s isn't an outline I can read: %s", path, err)
+	}
+	if len(outline.Slides) == 0 {
+		fatalf("%s doesn't have any slides in it", path)
+	}
+	logf("Loaded %d slides from: %s\n", len(outline.Slides), path)
+
+	// Outlines edited by hand might still have untitled slides in them
+	return titleUntitledSlides(outline)
+}
@@ -0,0 +1,107 @@
+package doctorslides
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// OutlineFormat is the set of markers GPT is asked to write the outline with.
+// The prompt and the parser both read from the same one so they can't drift
+// apart.
+type OutlineFormat struct {
+	SlideStart string `json:"slideStart"`
+	SlideEnd   string `json:"slideEnd"`
+	Title      string `json:"title"`
+	Bullet     string `json:"bullet"`
+	ImageURL   string `json:"imageUrl"`
+	Caption    string `json:"caption"`
+	Notes      string `json:"notes"`
+	Subtitle   string `json:"subtitle"`
+	Table      string `json:"table"`
+	Video      string `json:"video"`
+	Section    string `json:"section"`
+	Background string `json:"background"`
+}
+
+var DEFAULT_OUTLINE_FORMAT = OutlineFormat{
+	SlideStart: "NEW SLIDE ======",
+	SlideEnd:   "END SLIDE ======",
+	Title:      "Title: ",
+	Bullet:     "- ",
+	ImageURL:   "Image URL: ",
+	Caption:    "Caption: ",
+	Notes:      "Notes: ",
+	Subtitle:   "Subtitle: ",
+	Table:      "Table: ",
+	Video:      "Video: ",
+	Section:    "Section: ",
+	Background: "Background: ",
+}
+
+// OUTLINE_FORMAT is the format in use, which --format-config can change
+var OUTLINE_FORMAT = DEFAULT_OUTLINE_FORMAT
+
+// loadOutlineFormat reads a JSON file of markers. Anything the file leaves
+// out keeps its default.
+func loadOutlineFormat(path string) OutlineFormat {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fatalf("Could not read the format config: %s", err)
+	}
+	format := DEFAULT_OUTLINE_FORMAT
+	err = json.Unmarshal(content, &format)
+	if err != nil {
+		fatalf("The format config %s isn't valid JSON: %s", path, err)
+	}
+	markers := format.markers()
+	for i, marker := range markers {
+		if strings.TrimSpace(marker) == "" {
+			fatalf("The format config can't have an empty marker.")
+		}
+		for _, other := range markers[i+1:] {
+			if marker == other {
+				fatalf("The format config uses \"%s\" for two different things.", marker)
+			}
+		}
+	}
+
+	return format
+}
+
+func (format OutlineFormat) markers() []string {
+	return []string{
+		format.SlideStart,
+		format.SlideEnd,
+		format.Title,
+		format.Bullet,
+		format.ImageURL,
+		format.Caption,
+		format.Notes,
+		format.Subtitle,
+		format.Table,
+		format.Video,
+		format.Section,
+		format.Background,
+	}
+}
+
+// fillIn swaps the {{MARKER}} spots in a prompt template for the markers.
+// The code fence isn't up to the format, but it gets filled in the same way.
+func (format OutlineFormat) fillIn(template string) string {
+	return strings.NewReplacer(
+		"{{SLIDE_START}}", format.SlideStart,
+		"{{SLIDE_END}}", format.SlideEnd,
+		"{{TITLE}}", format.Title,
+		"{{BULLET}}", format.Bullet,
+		"{{IMAGE_URL}}", format.ImageURL,
+		"{{CAPTION}}", format.Caption,
+		"{{NOTES}}", format.Notes,
+		"{{SUBTITLE}}", format.Subtitle,
+		"{{TABLE}}", format.Table,
+		"{{VIDEO}}", format.Video,
+		"{{SECTION}}", format.Section,
+		"{{BACKGROUND}}", format.Background,
+		"{{CODE_FENCE}}", CODE_FENCE,
+	).Replace(template)
+}
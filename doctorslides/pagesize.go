@@ -0,0 +1,88 @@
+package doctorslides
+
+import (
+	"google.golang.org/api/slides/v1"
+	"math"
+	"sort"
+	"strings"
+)
+
+// PAGE_SIZES are the --size presets, in EMU. 16:9 is what Slides makes new
+// decks with anyway, and 4:3 is the old standard size.
+var PAGE_SIZES = map[string]ImagePlacement{
+	"16:9": {Width: PAGE_WIDTH, Height: PAGE_HEIGHT},
+	"4:3":  {Width: 9144000, Height: 6858000},
+}
+
+func pageSizeNames() string {
+	names := make([]string, 0, len(PAGE_SIZES))
+	for name := range PAGE_SIZES {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return strings.Join(append(names, "custom"), ", ")
+}
+
+// pageSize is the width and height of the deck's pages in EMU, which
+// everything placed by position works from
+func pageSize() (float64, float64) {
+	if options.pageWidth <= 0 || options.pageHeight <= 0 {
+		return PAGE_WIDTH, PAGE_HEIGHT
+	}
+
+	return options.pageWidth, options.pageHeight
+}
+
+// imageBox is the spot images go in, moved from where it sits on the
+// default page so it stays in the bottom right corner. It keeps its shape,
+// growing or shrinking only as much as fits both ways.
+func imageBox() ImagePlacement {
+	width, height := pageSize()
+	scaleX := width / PAGE_WIDTH
+	scaleY := height / PAGE_HEIGHT
+	scale := math.Min(scaleX, scaleY)
+
+	return ImagePlacement{
+		X:      IMAGE_BOX_X * scaleX,
+		Y:      IMAGE_BOX_Y * scaleY,
+		Width:  IMAGE_BOX_WIDTH * scale,
+		Height: IMAGE_BOX_HEIGHT * scale,
+	}
+}
+
+// scaledBox moves and stretches a box laid out for the default page so it
+// covers the same part of this one
+func scaledBox(box ImagePlacement) ImagePlacement {
+	width, height := pageSize()
+	scaleX := width / PAGE_WIDTH
+	scaleY := height / PAGE_HEIGHT
+
+	return ImagePlacement{
+		X:      box.X * scaleX,
+		Y:      box.Y * scaleY,
+		Width:  box.Width * scaleX,
+		Height: box.Height * scaleY,
+	}
+}
+
+func titleBox() ImagePlacement {
+	return scaledBox(TITLE_BOX)
+}
+
+func bodyBox() ImagePlacement {
+	return scaledBox(BODY_BOX)
+}
+
+// usePageSize goes by the size the deck actually came out as, which isn't
+// always the one asked for, like a deck started from an imported pptx
+func usePageSize(size *slides.Size) {
+	if size == nil || size.Width == nil || size.Height == nil {
+		return
+	}
+	width := toEMU(size.Width.Magnitude, size.Width.Unit)
+	height := toEMU(size.Height.Magnitude, size.Height.Unit)
+	if width > 0 && height > 0 {
+		options.pageWidth, options.pageHeight = width, height
+	}
+}
@@ -0,0 +1,177 @@
+package doctorslides
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// postProcessOutline runs the clean up passes over a freshly parsed outline.
+// Apart from filling in missing titles, each pass is off unless its flag was
+// given.
+func postProcessOutline(outline GPTOutline) GPTOutline {
+	// Titles come first since merging slides joins their titles together
+	outline = titleUntitledSlides(outline)
+	if options.MergeShortSlides {
+		outline = mergeShortSlides(outline, options.MergeThreshold)
+	}
+	if options.ExactSlides > 0 {
+		outline = fitToExactSlides(outline, options.ExactSlides)
+	}
+	// Notes come first so they're built from the full bullets, before any
+	// get shortened
+	if options.NotesFromBullets {
+		outline = notesFromBullets(outline, options.ExpandNotes)
+	}
+	if options.NotesWords > 0 {
+		outline = capOutlineNotes(outline, options.NotesWords)
+	}
+	if options.DedupeBullets {
+		outline = dedupeOutlineBullets(outline, options.DedupeThreshold)
+	}
+	if options.ConciseBullets {
+		outline = conciseOutlineBullets(outline, options.NotesFromBullets || options.TruncatedToNotes)
+	}
+	if options.MaxBullets > 0 && options.ContinuationStyle != "" {
+		_, maxSlides, bounded := slideCountBounds()
+		if !bounded {
+			maxSlides = 0
+		}
+		outline = splitOutlineBullets(outline, options.MaxBullets, options.ContinuationStyle, maxSlides, options.TruncatedToNotes)
+	} else if options.MaxBullets > 0 {
+		outline = capOutlineBullets(outline, options.MaxBullets, options.TruncatedToNotes)
+	}
+	if options.TruncateBullets {
+		outline = truncateOutlineBullets(outline, options.MaxBulletChars, options.TruncatedToNotes)
+	}
+	// Emojis go on last so they don't count against --max-bullet-chars
+	if options.EmojiBullets {
+		keywords := EMOJI_KEYWORDS
+		if options.EmojiMap != "" {
+			keywords = loadEmojiKeywords(options.EmojiMap)
+		}
+		outline = addEmojiBullets(outline, keywords)
+	}
+
+	return outline
+}
+
+// mergeShortSlides folds runs of slides with fewer than threshold bullets
+// together until they aren't short anymore. Slides without any bullets are
+// treated as section headers and never get merged, so nothing crosses from
+// one section into the next.
+func mergeShortSlides(outline GPTOutline, threshold int) GPTOutline {
+	isShort := func(slide SimpleSlide) bool {
+		return len(slide.Bullets) > 0 && len(slide.Bullets) < threshold
+	}
+	merged := make([]SimpleSlide, 0)
+	for _, slide := range outline.Slides {
+		last := len(merged) - 1
+		if last >= 0 && isShort(merged[last]) && isShort(slide) {
+			merged[last].Title = merged[last].Title + " & " + slide.Title
+			merged[last].Bullets = append(merged[last].Bullets, slide.Bullets...)
+			merged[last].Notes = strings.TrimSpace(merged[last].Notes + "\n" + slide.Notes)
+			if merged[last].Image == "" {
+				merged[last].Image = slide.Image
+				merged[last].Caption = slide.Caption
+			}
+			continue
+		}
+		merged = append(merged, slide)
+	}
+	if len(merged) < len(outline.Slides) && DEBUG {
+		logf("Merging short slides took the outline from %d slides to %d\n", len(outline.Slides), len(merged))
+	}
+	outline.Slides = merged
+
+	return outline
+}
+
+// fitToExactSlides makes sure there are exactly count slides. GPT usually
+// listens when asked for a number, but when it doesn't the extras get cut
+// and any missing ones become empty section headers to fill in by hand.
+func fitToExactSlides(outline GPTOutline, count int) GPTOutline {
+	if len(outline.Slides) > count {
+		logf("GPT made %d slides instead of %d, so the extras are getting cut\n", len(outline.Slides), count)
+		outline.Slides = outline.Slides[:count]
+	}
+	if len(outline.Slides) < count {
+		logf("GPT made %d slides instead of %d, so I'm adding section headers to make up the difference\n", len(outline.Slides), count)
+	}
+	for i := len(outline.Slides); i < count; i++ {
+		outline.Slides = append(outline.Slides, SimpleSlide{
+			Title:   fmt.Sprintf("Section %d", i+1),
+			Bullets: make([]Bullet, 0),
+		})
+	}
+
+	return outline
+}
+
+func truncateOutlineBullets(outline GPTOutline, limit int, keepInNotes bool) GPTOutline {
+	for i := range outline.Slides {
+		slide := &outline.Slides[i]
+		trimmed := make([]string, 0)
+		for j, bullet := range slide.Bullets {
+			short, wasTruncated := truncateBullet(bullet.Text, limit)
+			// The notes might already have the whole bullet if they were
+			// made from the bullets
+			if wasTruncated && keepInNotes && !strings.Contains(slide.Notes, bullet.Text) {
+				trimmed = append(trimmed, bullet.Text)
+			}
+			slide.Bullets[j].Text = short
+		}
+		if len(trimmed) > 0 {
+			slide.Notes = strings.TrimSpace(slide.Notes + "\n" + strings.Join(trimmed, "\n"))
+		}
+	}
+
+	return outline
+}
+
+// capOutlineBullets keeps the first limit bullets on each slide. The rest are
+// dropped, or moved into the speaker notes when keepInNotes is set.
+func capOutlineBullets(outline GPTOutline, limit int, keepInNotes bool) GPTOutline {
+	for i := range outline.Slides {
+		slide := &outline.Slides[i]
+		if len(slide.Bullets) <= limit {
+			continue
+		}
+		if keepInNotes {
+			dropped := bulletTexts(slide.Bullets[limit:])
+			slide.Notes = strings.TrimSpace(slide.Notes + "\n" + strings.Join(dropped, "\n"))
+		}
+		slide.Bullets = slide.Bullets[:limit]
+	}
+
+	return outline
+}
+
+// truncateBullet cuts a bullet down to at most limit characters, ellipsis
+// included, without chopping a word in half. The bool reports whether
+// anything was actually cut.
+func truncateBullet(bullet string, limit int) (string, bool) {
+	runes := []rune(bullet)
+	if limit <= 1 || len(runes) <= limit {
+		return bullet, false
+	}
+	// Save one character for the ellipsis
+	cut := runes[:limit-1]
+	if !unicode.IsSpace(runes[limit-1]) {
+		lastSpace := -1
+		for i, r := range cut {
+			if unicode.IsSpace(r) {
+				lastSpace = i
+			}
+		}
+		// A single giant word has nowhere nice to break, so it just gets cut
+		if lastSpace > 0 {
+			cut = cut[:lastSpace]
+		}
+	}
+	short := strings.TrimRightFunc(string(cut), func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(",.;:", r)
+	})
+
+	return short + "…", true
+}
@@ -0,0 +1,94 @@
+package doctorslides
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTruncateBullet(t *testing.T) {
+	tests := []struct {
+		name          string
+		bullet        string
+		limit         int
+		want          string
+		wantTruncated bool
+	}{
+		{"exactly at the limit", "hello world", 11, "hello world", false},
+		{"one character over", "hello world!", 11, "hello…", true},
+		{"multi-word overflow", "the quick brown fox jumps", 12, "the quick…", true},
+		{"single long word", "supercalifragilistic", 10, "supercali…", true},
+		{"punctuation before the cut", "one, two three", 6, "one…", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, truncated := truncateBullet(test.bullet, test.limit)
+			if got != test.want || truncated != test.wantTruncated {
+				t.Errorf("truncateBullet(%q, %d) = %q, %v, want %q, %v", test.bullet, test.limit, got, truncated, test.want, test.wantTruncated)
+			}
+			if length := len([]rune(got)); length > test.limit {
+				t.Errorf("truncateBullet(%q, %d) is %d characters, over the limit", test.bullet, test.limit, length)
+			}
+		})
+	}
+}
+
+func bulletSlide(title string, bullets ...string) SimpleSlide {
+	slide := SimpleSlide{Title: title, Bullets: make([]Bullet, 0)}
+	for _, text := range bullets {
+		slide.Bullets = append(slide.Bullets, Bullet{Text: text})
+	}
+
+	return slide
+}
+
+func slideTitles(outline GPTOutline) []string {
+	titles := make([]string, 0, len(outline.Slides))
+	for _, slide := range outline.Slides {
+		titles = append(titles, slide.Title)
+	}
+
+	return titles
+}
+
+func TestMergeShortSlides(t *testing.T) {
+	useDefaultOptions(t)
+	tests := []struct {
+		name        string
+		slides      []SimpleSlide
+		wantTitles  []string
+		wantBullets []int
+	}{
+		{
+			name:        "two single bullet slides merge",
+			slides:      []SimpleSlide{bulletSlide("One", "a"), bulletSlide("Two", "b")},
+			wantTitles:  []string{"One & Two"},
+			wantBullets: []int{2},
+		},
+		{
+			name:        "a full slide is left alone",
+			slides:      []SimpleSlide{bulletSlide("Full", "a", "b", "c"), bulletSlide("Short", "d")},
+			wantTitles:  []string{"Full", "Short"},
+			wantBullets: []int{3, 1},
+		},
+		{
+			name:        "section headers are never merged",
+			slides:      []SimpleSlide{bulletSlide("One", "a"), bulletSlide("Part Two"), bulletSlide("Two", "b")},
+			wantTitles:  []string{"One", "Part Two", "Two"},
+			wantBullets: []int{1, 0, 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged := mergeShortSlides(GPTOutline{Slides: test.slides}, 2)
+			titles := slideTitles(merged)
+			if strings.Join(titles, "/") != strings.Join(test.wantTitles, "/") {
+				t.Fatalf("merged into %q, want %q", titles, test.wantTitles)
+			}
+			for i, slide := range merged.Slides {
+				if len(slide.Bullets) != test.wantBullets[i] {
+					t.Errorf("%s has %d bullets, want %d", slide.Title, len(slide.Bullets), test.wantBullets[i])
+				}
+			}
+		})
+	}
+}
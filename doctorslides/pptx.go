@@ -0,0 +1,393 @@
+package doctorslides
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// --target onedrive builds its pptx right here instead of through Google
+// Slides. It's about the smallest PowerPoint file there is: one master, a
+// title layout and a title and content layout, and a plain theme. Only the
+// titles and bullets make it onto the slides, everything else in the
+// outline that needs the Slides API is left off.
+
+const (
+	PPTX_NS_A   = "http://schemas.openxmlformats.org/drawingml/2006/main"
+	PPTX_NS_R   = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	PPTX_NS_P   = "http://schemas.openxmlformats.org/presentationml/2006/main"
+	PPTX_NS_REL = "http://schemas.openxmlformats.org/package/2006/relationships"
+)
+
+const (
+	PPTX_REL_DOCUMENT = PPTX_NS_R + "/officeDocument"
+	PPTX_REL_MASTER   = PPTX_NS_R + "/slideMaster"
+	PPTX_REL_LAYOUT   = PPTX_NS_R + "/slideLayout"
+	PPTX_REL_SLIDE    = PPTX_NS_R + "/slide"
+	PPTX_REL_THEME    = PPTX_NS_R + "/theme"
+)
+
+const PPTX_CONTENT_TYPE = "application/vnd.openxmlformats-officedocument."
+
+// pptxSlide is one slide of the local pptx. Title slides use the first
+// layout and put the body in the subtitle, everything else uses the second
+// with the body as bullets.
+type pptxSlide struct {
+	Title   string
+	Body    []Bullet
+	IsTitle bool
+}
+
+// pptxSlides lays the outline out the same way writeSlides does: the title
+// slide unless --no-title-slide, the content, and the closing slide
+func pptxSlides(outline GPTOutline) []pptxSlide {
+	deck := make([]pptxSlide, 0, len(outline.Slides)+2)
+	if !options.NoTitleSlide {
+		subtitle := outline.Subtitle
+		if options.Subtitle != "" {
+			subtitle = options.Subtitle
+		}
+		title := pptxSlide{Title: outline.Title, IsTitle: true}
+		if subtitle != "" {
+			title.Body = []Bullet{{Text: subtitle}}
+		}
+		deck = append(deck, title)
+	}
+	for _, slide := range outline.Slides {
+		body := make([]Bullet, 0, len(slide.Bullets))
+		for _, bullet := range slide.Bullets {
+			bullet.Text = checkboxMarker(bullet) + bullet.Text
+			body = append(body, bullet)
+		}
+		deck = append(deck, pptxSlide{Title: slide.Title, Body: body})
+	}
+	deck = append(deck, pptxSlide{Title: "The End", IsTitle: true})
+
+	return deck
+}
+
+// PPTX_IGNORED_FLAGS change how the Google Slides deck looks, and the pptx
+// built here has nothing to hang them on yet
+var PPTX_IGNORED_FLAGS = []string{
+	"theme",
+	"agenda",
+	"qr-source",
+	"qr-url",
+	"background-image",
+	"background-on-title",
+	"footer",
+	"author",
+	"footer-date",
+	"footer-position",
+	"page-numbers",
+	"number-closing-slide",
+	"image-source",
+	"image-credit",
+	"image-fit",
+	"transition",
+	"auto-advance",
+	"body-align",
+	"body-valign",
+	"autofit",
+	"no-bullets",
+	"bullet-glyph",
+	"numbered-bullets",
+	"number-style",
+	"color-sections",
+	"embed-outline",
+}
+
+// logPptxLeftOff says what the pptx won't have that the Google deck would,
+// both the slides with more than titles and bullets and any of
+// PPTX_IGNORED_FLAGS that were asked for. what is whoever's building it.
+func logPptxLeftOff(outline GPTOutline, what string) {
+	if skipped := leftOffPptx(outline); skipped > 0 {
+		logf("%d slides have images, notes, tables, code, or videos, which %s leaves off\n", skipped, what)
+	}
+	given := make([]string, 0)
+	for _, name := range PPTX_IGNORED_FLAGS {
+		if options.explicit[name] {
+			given = append(given, "--"+name)
+		}
+	}
+	if len(given) > 0 {
+		logf("%s only has the titles and bullets, so it's ignoring %s\n", what, strings.Join(given, ", "))
+	}
+}
+
+// leftOffPptx counts the slides with something on them the local pptx
+// doesn't carry over, so it can be mentioned once instead of per slide
+func leftOffPptx(outline GPTOutline) int {
+	count := 0
+	for _, slide := range outline.Slides {
+		if slide.Image != "" || slide.DocImage != "" || slide.Notes != "" || len(slide.Table) > 0 || slide.Code != "" || slide.Video != "" {
+			count++
+		}
+	}
+
+	return count
+}
+
+// pptxPart is one file inside the pptx zip
+type pptxPart struct {
+	name    string
+	content string
+}
+
+// buildPptx writes the whole pptx file for the outline
+func buildPptx(outline GPTOutline) ([]byte, error) {
+	deck := pptxSlides(outline)
+	width, height := pageSize()
+	parts := []pptxPart{
+		{"[Content_Types].xml", pptxContentTypes(len(deck))},
+		{"_rels/.rels", pptxRels([]string{PPTX_REL_DOCUMENT, "ppt/presentation.xml"})},
+		{"ppt/presentation.xml", pptxPresentation(len(deck), width, height)},
+		{"ppt/_rels/presentation.xml.rels", pptxPresentationRels(len(deck))},
+		{"ppt/slideMasters/slideMaster1.xml", pptxMaster(width, height)},
+		{"ppt/slideMasters/_rels/slideMaster1.xml.rels", pptxRels([]string{
+			PPTX_REL_LAYOUT, "../slideLayouts/slideLayout1.xml",
+			PPTX_REL_LAYOUT, "../slideLayouts/slideLayout2.xml",
+			PPTX_REL_THEME, "../theme/theme1.xml",
+		})},
+		{"ppt/slideLayouts/slideLayout1.xml", pptxTitleLayout(width, height)},
+		{"ppt/slideLayouts/_rels/slideLayout1.xml.rels", pptxRels([]string{PPTX_REL_MASTER, "../slideMasters/slideMaster1.xml"})},
+		{"ppt/slideLayouts/slideLayout2.xml", pptxContentLayout()},
+		{"ppt/slideLayouts/_rels/slideLayout2.xml.rels", pptxRels([]string{PPTX_REL_MASTER, "../slideMasters/slideMaster1.xml"})},
+		{"ppt/theme/theme1.xml", PPTX_THEME},
+	}
+	for i, slide := range deck {
+		layout := "../slideLayouts/slideLayout2.xml"
+		if slide.IsTitle {
+			layout = "../slideLayouts/slideLayout1.xml"
+		}
+		parts = append(parts,
+			pptxPart{fmt.Sprintf("ppt/slides/slide%d.xml", i+1), pptxSlideXML(slide)},
+			pptxPart{fmt.Sprintf("ppt/slides/_rels/slide%d.xml.rels", i+1), pptxRels([]string{PPTX_REL_LAYOUT, layout})},
+		)
+	}
+
+	var b bytes.Buffer
+	archive := zip.NewWriter(&b)
+	for _, part := range parts {
+		w, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(xml.Header + part.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func pptxEscape(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+
+	return b.String()
+}
+
+func pptxContentTypes(slideCount int) string {
+	var b strings.Builder
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	override := func(part string, contentType string) {
+		fmt.Fprintf(&b, `<Override PartName="%s" ContentType="%s%s"/>`, part, PPTX_CONTENT_TYPE, contentType)
+	}
+	override("/ppt/presentation.xml", "presentationml.presentation.main+xml")
+	override("/ppt/slideMasters/slideMaster1.xml", "presentationml.slideMaster+xml")
+	override("/ppt/slideLayouts/slideLayout1.xml", "presentationml.slideLayout+xml")
+	override("/ppt/slideLayouts/slideLayout2.xml", "presentationml.slideLayout+xml")
+	override("/ppt/theme/theme1.xml", "theme+xml")
+	for i := 1; i <= slideCount; i++ {
+		override(fmt.Sprintf("/ppt/slides/slide%d.xml", i), "presentationml.slide+xml")
+	}
+	b.WriteString(`</Types>`)
+
+	return b.String()
+}
+
+// pptxRels lists relationships as type and target pairs, numbered rId1 on in
+// the order they're given
+func pptxRels(pairs []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<Relationships xmlns="%s">`, PPTX_NS_REL)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="%s" Target="%s"/>`, i/2+1, pairs[i], pairs[i+1])
+	}
+	b.WriteString(`</Relationships>`)
+
+	return b.String()
+}
+
+// The presentation's own rels have the master first, the theme second, and
+// then the slides from rId3 on
+func pptxPresentationRels(slideCount int) string {
+	pairs := []string{PPTX_REL_MASTER, "slideMasters/slideMaster1.xml", PPTX_REL_THEME, "theme/theme1.xml"}
+	for i := 1; i <= slideCount; i++ {
+		pairs = append(pairs, PPTX_REL_SLIDE, fmt.Sprintf("slides/slide%d.xml", i))
+	}
+
+	return pptxRels(pairs)
+}
+
+func pptxPresentation(slideCount int, width float64, height float64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<p:presentation xmlns:a="%s" xmlns:r="%s" xmlns:p="%s" saveSubsetFonts="1">`, PPTX_NS_A, PPTX_NS_R, PPTX_NS_P)
+	b.WriteString(`<p:sldMasterIdLst><p:sldMasterId id="2147483648" r:id="rId1"/></p:sldMasterIdLst>`)
+	b.WriteString(`<p:sldIdLst>`)
+	for i := 0; i < slideCount; i++ {
+		fmt.Fprintf(&b, `<p:sldId id="%d" r:id="rId%d"/>`, 256+i, i+3)
+	}
+	b.WriteString(`</p:sldIdLst>`)
+	fmt.Fprintf(&b, `<p:sldSz cx="%d" cy="%d"/>`, int64(width), int64(height))
+	b.WriteString(`<p:notesSz cx="6858000" cy="9144000"/>`)
+	b.WriteString(`</p:presentation>`)
+
+	return b.String()
+}
+
+// pptxBox is where a placeholder sits, as fractions of the page
+type pptxBox struct {
+	X, Y, W, H float64
+}
+
+var (
+	PPTX_TITLE_BOX    = pptxBox{0.075, 0.06, 0.85, 0.17}
+	PPTX_BODY_BOX     = pptxBox{0.075, 0.26, 0.85, 0.66}
+	PPTX_CENTER_BOX   = pptxBox{0.1, 0.28, 0.8, 0.24}
+	PPTX_SUBTITLE_BOX = pptxBox{0.15, 0.55, 0.7, 0.16}
+)
+
+// PPTX_EMPTY_TEXT is the text a placeholder has on a master or layout
+const PPTX_EMPTY_TEXT = `<a:bodyPr/><a:lstStyle/><a:p><a:endParaRPr lang="en-US"/></a:p>`
+
+func pptxXfrm(box pptxBox, width float64, height float64) string {
+	return fmt.Sprintf(`<a:xfrm><a:off x="%d" y="%d"/><a:ext cx="%d" cy="%d"/></a:xfrm>`,
+		int64(box.X*width), int64(box.Y*height), int64(box.W*width), int64(box.H*height))
+}
+
+// pptxPlaceholder is a placeholder shape with its box, or with no box so it
+// sits wherever the layout has it
+func pptxPlaceholder(id int, name string, placeholder string, xfrm string, body string) string {
+	return fmt.Sprintf(`<p:sp><p:nvSpPr><p:cNvPr id="%d" name="%s"/><p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr><p:nvPr>%s</p:nvPr></p:nvSpPr><p:spPr>%s</p:spPr><p:txBody>%s</p:txBody></p:sp>`,
+		id, name, placeholder, xfrm, body)
+}
+
+func pptxShapeTree(name string, shapes ...string) string {
+	cSld := `<p:cSld>`
+	if name != "" {
+		cSld = fmt.Sprintf(`<p:cSld name="%s">`, name)
+	}
+
+	return cSld + `<p:spTree><p:nvGrpSpPr><p:cNvPr id="1" name=""/><p:cNvGrpSpPr/><p:nvPr/></p:nvGrpSpPr><p:grpSpPr/>` +
+		strings.Join(shapes, "") + `</p:spTree></p:cSld>`
+}
+
+func pptxMaster(width float64, height float64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<p:sldMaster xmlns:a="%s" xmlns:r="%s" xmlns:p="%s">`, PPTX_NS_A, PPTX_NS_R, PPTX_NS_P)
+	b.WriteString(`<p:cSld><p:bg><p:bgRef idx="1001"><a:schemeClr val="bg1"/></p:bgRef></p:bg><p:spTree><p:nvGrpSpPr><p:cNvPr id="1" name=""/><p:cNvGrpSpPr/><p:nvPr/></p:nvGrpSpPr><p:grpSpPr/>`)
+	b.WriteString(pptxPlaceholder(2, "Title Placeholder 1", `<p:ph type="title"/>`, pptxXfrm(PPTX_TITLE_BOX, width, height), PPTX_EMPTY_TEXT))
+	b.WriteString(pptxPlaceholder(3, "Text Placeholder 2", `<p:ph type="body" idx="1"/>`, pptxXfrm(PPTX_BODY_BOX, width, height), PPTX_EMPTY_TEXT))
+	b.WriteString(`</p:spTree></p:cSld>`)
+	b.WriteString(`<p:clrMap bg1="lt1" tx1="dk1" bg2="lt2" tx2="dk2" accent1="accent1" accent2="accent2" accent3="accent3" accent4="accent4" accent5="accent5" accent6="accent6" hlink="hlink" folHlink="folHlink"/>`)
+	b.WriteString(`<p:sldLayoutIdLst><p:sldLayoutId id="2147483649" r:id="rId1"/><p:sldLayoutId id="2147483650" r:id="rId2"/></p:sldLayoutIdLst>`)
+	b.WriteString(`<p:txStyles>`)
+	b.WriteString(`<p:titleStyle><a:lvl1pPr algn="l"><a:defRPr sz="4000" b="1"><a:solidFill><a:schemeClr val="tx1"/></a:solidFill><a:latin typeface="+mj-lt"/></a:defRPr></a:lvl1pPr></p:titleStyle>`)
+	b.WriteString(`<p:bodyStyle>`)
+	for level := 0; level < 5; level++ {
+		fmt.Fprintf(&b, `<a:lvl%dpPr marL="%d" indent="-285750"><a:buFont typeface="Arial"/><a:buChar char="•"/><a:defRPr sz="%d"><a:solidFill><a:schemeClr val="tx1"/></a:solidFill><a:latin typeface="+mn-lt"/></a:defRPr></a:lvl%dpPr>`,
+			level+1, 285750+level*457200, 2400-level*200, level+1)
+	}
+	b.WriteString(`</p:bodyStyle>`)
+	b.WriteString(`<p:otherStyle><a:lvl1pPr><a:defRPr sz="1800"/></a:lvl1pPr></p:otherStyle>`)
+	b.WriteString(`</p:txStyles></p:sldMaster>`)
+
+	return b.String()
+}
+
+func pptxTitleLayout(width float64, height float64) string {
+	return fmt.Sprintf(`<p:sldLayout xmlns:a="%s" xmlns:r="%s" xmlns:p="%s" type="title" preserve="1">`, PPTX_NS_A, PPTX_NS_R, PPTX_NS_P) +
+		pptxShapeTree("Title Slide",
+			pptxPlaceholder(2, "Title 1", `<p:ph type="ctrTitle"/>`, pptxXfrm(PPTX_CENTER_BOX, width, height), `<a:bodyPr anchor="b"/><a:lstStyle><a:lvl1pPr algn="ctr"><a:defRPr sz="5400"/></a:lvl1pPr></a:lstStyle><a:p><a:endParaRPr lang="en-US"/></a:p>`),
+			pptxPlaceholder(3, "Subtitle 2", `<p:ph type="subTitle" idx="1"/>`, pptxXfrm(PPTX_SUBTITLE_BOX, width, height), `<a:bodyPr/><a:lstStyle><a:lvl1pPr marL="0" indent="0" algn="ctr"><a:buNone/><a:defRPr sz="2400"/></a:lvl1pPr></a:lstStyle><a:p><a:endParaRPr lang="en-US"/></a:p>`),
+		) +
+		`<p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr></p:sldLayout>`
+}
+
+func pptxContentLayout() string {
+	return fmt.Sprintf(`<p:sldLayout xmlns:a="%s" xmlns:r="%s" xmlns:p="%s" type="obj" preserve="1">`, PPTX_NS_A, PPTX_NS_R, PPTX_NS_P) +
+		pptxShapeTree("Title and Content",
+			pptxPlaceholder(2, "Title 1", `<p:ph type="title"/>`, "", PPTX_EMPTY_TEXT),
+			pptxPlaceholder(3, "Content Placeholder 2", `<p:ph idx="1"/>`, "", PPTX_EMPTY_TEXT),
+		) +
+		`<p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr></p:sldLayout>`
+}
+
+func pptxRun(text string) string {
+	return fmt.Sprintf(`<a:r><a:rPr lang="en-US" dirty="0"/><a:t>%s</a:t></a:r>`, pptxEscape(text))
+}
+
+func pptxSlideXML(slide pptxSlide) string {
+	titlePlaceholder, bodyPlaceholder := `<p:ph type="title"/>`, `<p:ph idx="1"/>`
+	if slide.IsTitle {
+		titlePlaceholder, bodyPlaceholder = `<p:ph type="ctrTitle"/>`, `<p:ph type="subTitle" idx="1"/>`
+	}
+	shapes := []string{
+		pptxPlaceholder(2, "Title 1", titlePlaceholder, "", `<a:bodyPr/><a:lstStyle/><a:p>`+pptxRun(slide.Title)+`</a:p>`),
+	}
+	if len(slide.Body) > 0 {
+		var body strings.Builder
+		body.WriteString(`<a:bodyPr/><a:lstStyle/>`)
+		for _, bullet := range slide.Body {
+			level := bullet.Level
+			if level > 4 {
+				level = 4
+			}
+			fmt.Fprintf(&body, `<a:p><a:pPr lvl="%d"/>%s</a:p>`, level, pptxRun(bullet.Text))
+		}
+		shapes = append(shapes, pptxPlaceholder(3, "Content Placeholder 2", bodyPlaceholder, "", body.String()))
+	}
+
+	return fmt.Sprintf(`<p:sld xmlns:a="%s" xmlns:r="%s" xmlns:p="%s">`, PPTX_NS_A, PPTX_NS_R, PPTX_NS_P) +
+		pptxShapeTree("", shapes...) +
+		`<p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr></p:sld>`
+}
+
+// PPTX_THEME is Office's plain theme, cut down to what a theme has to have
+const PPTX_THEME = `<a:theme xmlns:a="` + PPTX_NS_A + `" name="Doctor Slides">` +
+	`<a:themeElements>` +
+	`<a:clrScheme name="Doctor Slides">` +
+	`<a:dk1><a:sysClr val="windowText" lastClr="000000"/></a:dk1>` +
+	`<a:lt1><a:sysClr val="window" lastClr="FFFFFF"/></a:lt1>` +
+	`<a:dk2><a:srgbClr val="44546A"/></a:dk2>` +
+	`<a:lt2><a:srgbClr val="E7E6E6"/></a:lt2>` +
+	`<a:accent1><a:srgbClr val="4472C4"/></a:accent1>` +
+	`<a:accent2><a:srgbClr val="ED7D31"/></a:accent2>` +
+	`<a:accent3><a:srgbClr val="A5A5A5"/></a:accent3>` +
+	`<a:accent4><a:srgbClr val="FFC000"/></a:accent4>` +
+	`<a:accent5><a:srgbClr val="5B9BD5"/></a:accent5>` +
+	`<a:accent6><a:srgbClr val="70AD47"/></a:accent6>` +
+	`<a:hlink><a:srgbClr val="0563C1"/></a:hlink>` +
+	`<a:folHlink><a:srgbClr val="954F72"/></a:folHlink>` +
+	`</a:clrScheme>` +
+	`<a:fontScheme name="Doctor Slides">` +
+	`<a:majorFont><a:latin typeface="Calibri Light"/><a:ea typeface=""/><a:cs typeface=""/></a:majorFont>` +
+	`<a:minorFont><a:latin typeface="Calibri"/><a:ea typeface=""/><a:cs typeface=""/></a:minorFont>` +
+	`</a:fontScheme>` +
+	`<a:fmtScheme name="Doctor Slides">` +
+	`<a:fillStyleLst><a:solidFill><a:schemeClr val="phClr"/></a:solidFill><a:solidFill><a:schemeClr val="phClr"/></a:solidFill><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:fillStyleLst>` +
+	`<a:lnStyleLst><a:ln w="6350"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln><a:ln w="12700"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln><a:ln w="19050"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln></a:lnStyleLst>` +
+	`<a:effectStyleLst><a:effectStyle><a:effectLst/></a:effectStyle><a:effectStyle><a:effectLst/></a:effectStyle><a:effectStyle><a:effectLst/></a:effectStyle></a:effectStyleLst>` +
+	`<a:bgFillStyleLst><a:solidFill><a:schemeClr val="phClr"/></a:solidFill><a:solidFill><a:schemeClr val="phClr"/></a:solidFill><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:bgFillStyleLst>` +
+	`</a:fmtScheme>` +
+	`</a:themeElements>` +
+	`</a:theme>`
@@ -0,0 +1,158 @@
+package doctorslides
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"testing"
+)
+
+// readPptx opens the pptx and hands back every part in it by name, failing
+// the test for any XML that doesn't parse
+func readPptx(t *testing.T, content []byte) map[string]string {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("the pptx isn't a zip: %s", err)
+	}
+	parts := make(map[string]string)
+	for _, file := range archive.File {
+		f, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoder := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			_, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s isn't well formed: %s", file.Name, err)
+			}
+		}
+		parts[file.Name] = string(data)
+	}
+
+	return parts
+}
+
+// relsTargets lists where a rels part points, resolved against the part the
+// rels belong to
+func relsTargets(t *testing.T, relsName string, rels string) []string {
+	var parsed struct {
+		Relationships []struct {
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := xml.Unmarshal([]byte(rels), &parsed); err != nil {
+		t.Fatalf("%s: %s", relsName, err)
+	}
+	// ppt/slides/_rels/slide1.xml.rels is for ppt/slides/slide1.xml
+	base := path.Dir(path.Dir(relsName))
+	targets := make([]string, 0, len(parsed.Relationships))
+	for _, relationship := range parsed.Relationships {
+		targets = append(targets, path.Clean(path.Join(base, relationship.Target)))
+	}
+
+	return targets
+}
+
+func TestBuildPptx(t *testing.T) {
+	useDefaultOptions(t)
+	outline := GPTOutline{
+		Title:    "Fish & Chips",
+		Subtitle: "A <short> history",
+		Slides: []SimpleSlide{
+			{Title: "Origins", Bullets: []Bullet{{Text: "London, 1860"}, {Text: "Joseph \"Malin\"", Level: 1}}},
+			{Title: "Today", Bullets: []Bullet{{Text: "Still popular", Checkbox: true, Checked: true}}, Notes: "Not in the pptx"},
+		},
+	}
+	content, err := buildPptx(outline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := readPptx(t, content)
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "ppt/presentation.xml", "ppt/theme/theme1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("the pptx has no %s", name)
+		}
+	}
+	// Title, two content slides, and the closing slide
+	for i, want := range []string{"Fish &amp; Chips", "Origins", "Today", "The End"} {
+		slide, ok := parts[fmt.Sprintf("ppt/slides/slide%d.xml", i+1)]
+		if !ok || !strings.Contains(slide, want) {
+			t.Errorf("slide %d should have %q on it: %s", i+1, want, slide)
+		}
+	}
+	if _, ok := parts["ppt/slides/slide5.xml"]; ok {
+		t.Errorf("the pptx has more slides than the outline")
+	}
+	for _, want := range []string{"A &lt;short&gt; history", `<a:pPr lvl="1"/><a:r><a:rPr lang="en-US" dirty="0"/><a:t>Joseph &#34;Malin&#34;</a:t>`, "[x] Still popular"} {
+		found := false
+		for _, part := range parts {
+			found = found || strings.Contains(part, want)
+		}
+		if !found {
+			t.Errorf("no part of the pptx has %q", want)
+		}
+	}
+
+	// Everything the rels point at has to be there, and have a content type
+	for name, part := range parts {
+		if !strings.HasSuffix(name, ".rels") {
+			continue
+		}
+		for _, target := range relsTargets(t, name, part) {
+			if _, ok := parts[target]; !ok {
+				t.Errorf("%s points at %s, which isn't in the pptx", name, target)
+			}
+			if !strings.Contains(parts["[Content_Types].xml"], `PartName="/`+target+`"`) {
+				t.Errorf("%s has no content type", target)
+			}
+		}
+	}
+}
+
+func TestOneDriveScopes(t *testing.T) {
+	useDefaultOptions(t)
+	options.command = "generate"
+	options.Target = "onedrive"
+	for _, scope := range requiredScopes() {
+		if scope != SCOPE_DOCUMENTS_READONLY {
+			t.Errorf("--target onedrive asked Google for %s", scope)
+		}
+	}
+}
+
+func TestPptxExportScopes(t *testing.T) {
+	useDefaultOptions(t)
+	options.command = "export"
+	options.ExportFormat = "pptx"
+	for _, scope := range requiredScopes() {
+		if scope != SCOPE_DOCUMENTS_READONLY {
+			t.Errorf("export --format pptx asked Google for %s", scope)
+		}
+	}
+}
+
+func TestPptxIgnoredFlags(t *testing.T) {
+	useDefaultOptions(t)
+	var log bytes.Buffer
+	LOG = &log
+	options.explicit = map[string]bool{"theme": true, "page-numbers": true, "size": true}
+	logPptxLeftOff(GPTOutline{Slides: []SimpleSlide{{Title: "Plain"}}}, "the pptx export")
+	if got := log.String(); got != "the pptx export only has the titles and bullets, so it's ignoring --theme, --page-numbers\n" {
+		t.Errorf("log was %q", got)
+	}
+}
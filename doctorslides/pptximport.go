@@ -0,0 +1,114 @@
+package doctorslides
+
+import (
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/slides/v1"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// importedSlideWriter builds the new slides onto the end of a deck that came
+// from --import-pptx. The slides that were already there are hidden from
+// writeSlides, so it sees the same thing it would with a brand new deck: a
+// single TITLE slide it can fill in, with everything it adds after that.
+type importedSlideWriter struct {
+	liveSlideWriter
+	presentationId string
+	existing       int
+}
+
+func (w *importedSlideWriter) Create(presentation *slides.Presentation) (*slides.Presentation, error) {
+	imported, err := w.liveSlideWriter.Get(w.presentationId, "slides(objectId)")
+	if err != nil {
+		return nil, err
+	}
+	w.existing = len(imported.Slides)
+	_, err = w.liveSlideWriter.BatchUpdate(w.presentationId, &slides.BatchUpdatePresentationRequest{
+		Requests: []*slides.Request{
+			{
+				CreateSlide: &slides.CreateSlideRequest{
+					SlideLayoutReference: &slides.LayoutReference{
+						PredefinedLayout: "TITLE",
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return w.Get(w.presentationId, SLIDE_LAYOUT_FIELDS)
+}
+
+// BatchUpdate moves any slides placed at a set spot past the imported ones,
+// since writeSlides counts from what it thinks is the start of the deck
+func (w *importedSlideWriter) BatchUpdate(presentationId string, updates *slides.BatchUpdatePresentationRequest) (*slides.BatchUpdatePresentationResponse, error) {
+	shifted := slides.BatchUpdatePresentationRequest{Requests: make([]*slides.Request, 0, len(updates.Requests))}
+	for _, request := range updates.Requests {
+		if request.CreateSlide != nil && (request.CreateSlide.InsertionIndex > 0 || isOneOf("InsertionIndex", request.CreateSlide.ForceSendFields)) {
+			createSlide := *request.CreateSlide
+			createSlide.InsertionIndex += int64(w.existing)
+			request = &slides.Request{CreateSlide: &createSlide}
+		}
+		shifted.Requests = append(shifted.Requests, request)
+	}
+
+	return w.liveSlideWriter.BatchUpdate(presentationId, &shifted)
+}
+
+func (w *importedSlideWriter) Get(presentationId string, fields ...googleapi.Field) (*slides.Presentation, error) {
+	presentation, err := w.liveSlideWriter.Get(presentationId, fields...)
+	if err != nil {
+		return nil, err
+	}
+	if len(presentation.Slides) >= w.existing {
+		presentation.Slides = presentation.Slides[w.existing:]
+	}
+
+	return presentation, nil
+}
+
+// importPptx uploads a PowerPoint file to Drive and has Drive turn it into
+// Google Slides on the way in. The converted deck is a new file, so the
+// .pptx itself only ends up in Drive too with --keep-pptx.
+func importPptx(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	logf("Uploading %s to Drive\n", filepath.Base(path))
+	driveService := getDriveService()
+	converted, err := uploadFile(driveService, path, &drive.File{Name: name, MimeType: GOOGLE_SLIDES_MIME_TYPE})
+	if err != nil {
+		logln("Could not import the PowerPoint file")
+		panic(err)
+	}
+	if DEBUG {
+		logf("Imported %s as presentation %s\n", path, converted.Id)
+	}
+	if options.KeepPptx {
+		original, err := uploadFile(driveService, path, &drive.File{Name: filepath.Base(path), MimeType: PPTX_MIME_TYPE})
+		if err != nil {
+			logf("Could not keep a copy of the original in Drive: %s\n", err)
+		} else if folderId := outputFolder(); folderId != "" {
+			moveToFolder(original.Id, folderId)
+		}
+	}
+
+	return converted.Id
+}
+
+func uploadFile(driveService *drive.Service, path string, file *drive.File) (*drive.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return driveService.Files.Create(file).
+		Media(f, googleapi.ContentType(PPTX_MIME_TYPE)).
+		SupportsAllDrives(true).
+		Fields("id").
+		Context(CTX).
+		Do()
+}
@@ -0,0 +1,103 @@
+package doctorslides
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Preset is a named bundle of flag values for a common kind of deck
+type Preset struct {
+	Name        string
+	Description string
+	Flags       map[string]string
+}
+
+// PRESETS are listed in the order they show up in the help
+var PRESETS = []Preset{
+	{
+		Name:        "pitch",
+		Description: "exactly 10 slides, fade transitions, and short punchy bullets",
+		Flags: map[string]string{
+			"exact-slides":     "10",
+			"transition":       "FADE",
+			"max-bullets":      "4",
+			"truncate-bullets": "true",
+			"max-bullet-chars": "80",
+		},
+	},
+	{
+		Name:        "lecture",
+		Description: "more slides, with speaker notes made from the bullets",
+		Flags: map[string]string{
+			"min-slides":         "12",
+			"max-slides":         "30",
+			"notes-from-bullets": "true",
+		},
+	},
+	{
+		Name:        "handout",
+		Description: "no images, and a markdown file when used with export",
+		Flags: map[string]string{
+			"format":       "md",
+			"image-source": "none",
+		},
+	},
+}
+
+func findPreset(name string) (Preset, bool) {
+	for _, preset := range PRESETS {
+		if preset.Name == name {
+			return preset, true
+		}
+	}
+
+	return Preset{}, false
+}
+
+func presetNames() string {
+	names := make([]string, 0)
+	for _, preset := range PRESETS {
+		names = append(names, preset.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// presetHelp is the list of presets for the usage text
+func presetHelp() string {
+	var b strings.Builder
+	b.WriteString("Presets (--preset <name>):\n")
+	for _, preset := range PRESETS {
+		fmt.Fprintf(&b, "  %-9s %s\n", preset.Name, preset.Description)
+	}
+
+	return b.String()
+}
+
+// applyPreset fills in the preset's values for any flag that wasn't given on
+// the command line, so an explicit flag always wins. Flags the subcommand
+// doesn't have (like --format for generate) are skipped.
+func applyPreset(fs *flag.FlagSet, name string) {
+	if name == "" {
+		return
+	}
+	preset, ok := findPreset(name)
+	if !ok {
+		fatalf("I don't know the preset \"%s\". Try one of: %s", name, presetNames())
+	}
+	for flagName, value := range preset.Flags {
+		if options.explicit[flagName] || fs.Lookup(flagName) == nil {
+			continue
+		}
+		// Asking for a slide range by hand means the preset's exact count
+		// has to step aside, or the two would fight
+		if flagName == "exact-slides" && (options.explicit["min-slides"] || options.explicit["max-slides"]) {
+			continue
+		}
+		err := fs.Set(flagName, value)
+		if err != nil {
+			fatalf("The %s preset has a bad value for --%s: %s", name, flagName, err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package doctorslides
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// PROMPT_TEMPLATE is the --prompt-file template, when there is one, and
+// takes the place of the whole outline prompt
+var PROMPT_TEMPLATE *template.Template
+
+// PromptData is what a --prompt-file template gets to work with. The format
+// markers are there so a custom prompt can spell out the delimiters without
+// hard coding them.
+type PromptData struct {
+	Content     string
+	MinSlides   int
+	MaxSlides   int
+	ExactSlides int
+	SlideCount  string
+	SlideStart  string
+	SlideEnd    string
+	Title       string
+	Bullet      string
+	ImageURL    string
+	Caption     string
+	Notes       string
+	Subtitle    string
+	Table       string
+	Video       string
+	Section     string
+	Background  string
+}
+
+func promptData(content string) PromptData {
+	format := OUTLINE_FORMAT
+
+	return PromptData{
+		Content:     content,
+		MinSlides:   options.MinSlides,
+		MaxSlides:   options.MaxSlides,
+		ExactSlides: options.ExactSlides,
+		SlideCount:  slideCountInstruction(),
+		SlideStart:  format.SlideStart,
+		SlideEnd:    format.SlideEnd,
+		Title:       format.Title,
+		Bullet:      format.Bullet,
+		ImageURL:    format.ImageURL,
+		Caption:     format.Caption,
+		Notes:       format.Notes,
+		Subtitle:    format.Subtitle,
+		Table:       format.Table,
+		Video:       format.Video,
+		Section:     format.Section,
+		Background:  format.Background,
+	}
+}
+
+// loadPromptTemplate reads --prompt-file and tries it out before any tokens
+// get spent on it. Leaving out the document is never going to work, so that
+// stops the run. Leaving out the delimiters might, if the prompt describes
+// them some other way, but the parser counts on them so it gets a warning.
+func loadPromptTemplate(path string) *template.Template {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fatalf("Could not read the prompt file: %s", err)
+	}
+	prompt, err := template.New(path).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		fatalf("The prompt file %s isn't a valid template: %s", path, err)
+	}
+	if !strings.Contains(string(content), ".Content") {
+		fatalf("The prompt file %s never uses {{.Content}}, so GPT would never see the document.", path)
+	}
+	rendered, err := renderPrompt(prompt, "")
+	if err != nil {
+		fatalf("The prompt file %s doesn't fill in: %s", path, err)
+	}
+	format := OUTLINE_FORMAT
+	missing := make([]string, 0)
+	for _, marker := range []string{format.SlideStart, format.SlideEnd, format.Title, format.Bullet} {
+		if !strings.Contains(rendered, strings.TrimSpace(marker)) {
+			missing = append(missing, "\""+strings.TrimSpace(marker)+"\"")
+		}
+	}
+	if len(missing) > 0 {
+		logln("!!! WARNING !!!")
+		logf("The prompt file %s never mentions %s. The outline is read by looking for those, so unless GPT uses them anyway you'll get nothing back. Try {{.SlideStart}}, {{.SlideEnd}}, {{.Title}} and {{.Bullet}}.\n", path, strings.Join(missing, ", "))
+	}
+
+	return prompt
+}
+
+func renderPrompt(prompt *template.Template, content string) (string, error) {
+	var b bytes.Buffer
+	err := prompt.Execute(&b, promptData(content))
+
+	return b.String(), err
+}
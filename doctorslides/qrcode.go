@@ -0,0 +1,102 @@
+package doctorslides
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/slides/v1"
+)
+
+const (
+	QR_CODE_SIZE   = 1371600
+	QR_CODE_PIXELS = 400
+)
+
+func documentURL(documentId string) string {
+	return fmt.Sprintf("https://docs.google.com/document/d/%s/edit", documentId)
+}
+
+// qrTarget is what the QR code should open, --qr-url if it was given and the
+// source doc otherwise. It's empty when there's nothing to point at, like an
+// outline saved before we kept track of where it came from.
+func qrTarget(outline GPTOutline) string {
+	if options.QRURL != "" {
+		return options.QRURL
+	}
+
+	return outline.SourceURL
+}
+
+// hostQRCode puts the QR code somewhere Slides can download it from, since
+// CreateImage only takes a URL. It goes up to Drive readable by anyone with
+// the link, and the function handed back deletes it again once Slides has
+// its own copy. It's a variable so the tests can stand in for Drive.
+var hostQRCode = func(content []byte) (string, func(), error) {
+	driveService := getDriveService()
+	file, err := driveService.Files.Create(&drive.File{Name: "Doctor Slides QR code.png", MimeType: "image/png"}).
+		Media(bytes.NewReader(content), googleapi.ContentType("image/png")).
+		Fields("id,webContentLink").
+		Context(CTX).
+		Do()
+	if err != nil {
+		return "", nil, err
+	}
+	// This still runs after a Ctrl+C, so the picture doesn't get left
+	// sitting in Drive
+	remove := func() {
+		if err := driveService.Files.Delete(file.Id).Context(context.Background()).Do(); err != nil {
+			logf("Could not delete the QR code picture from Drive, it's the file %s: %s\n", file.Id, err)
+		}
+	}
+	_, err = driveService.Permissions.Create(file.Id, &drive.Permission{Type: "anyone", Role: "reader"}).Context(CTX).Do()
+	if err != nil {
+		remove()
+		return "", nil, err
+	}
+
+	imageURL := file.WebContentLink
+	if imageURL == "" {
+		imageURL = fmt.Sprintf("https://drive.google.com/uc?id=%s&export=download", file.Id)
+	}
+
+	return imageURL, remove, nil
+}
+
+// qrCodeImage draws the QR code and hosts it for Slides. The cleanup
+// function is always safe to call, even when it failed.
+func qrCodeImage(target string) (string, func(), error) {
+	content, err := qrCodePNG(target, QR_CODE_PIXELS)
+	if err != nil {
+		return "", func() {}, err
+	}
+	imageURL, remove, err := hostQRCode(content)
+	if err != nil {
+		return "", func() {}, err
+	}
+
+	return imageURL, remove, nil
+}
+
+// buildQRCodeRequests puts the QR code in the bottom right of the closing
+// slide, out of the way of "The End" and sitting above where a page number
+// would go
+func buildQRCodeRequests(slideId string, imageURL string) []*slides.Request {
+	pageWidth, pageHeight := pageSize()
+
+	return []*slides.Request{
+		{
+			CreateImage: &slides.CreateImageRequest{
+				ObjectId: "qr_code",
+				Url:      imageURL,
+				ElementProperties: elementProperties(slideId, ImagePlacement{
+					X:      pageWidth - QR_CODE_SIZE - FOOTER_MARGIN,
+					Y:      pageHeight - QR_CODE_SIZE - FOOTER_HEIGHT - FOOTER_MARGIN,
+					Width:  QR_CODE_SIZE,
+					Height: QR_CODE_SIZE,
+				}),
+			},
+		},
+	}
+}
@@ -0,0 +1,477 @@
+package doctorslides
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// This is just enough of a QR code encoder for the links on the closing
+// slide: byte mode at error correction level M, versions 1 to 10, which is
+// up to 213 bytes. That's plenty for a doc URL, and anything longer would
+// be hard to scan at the size it's shown anyway.
+
+// qrBlocks is how a QR version splits its codewords into error correction
+// blocks at level M. Long blocks have one more data codeword than short ones.
+type qrBlocks struct {
+	short     int
+	long      int
+	shortData int
+	ecc       int
+}
+
+// QR_VERSIONS_M are versions 1 to 10 at level M, with the centers of their
+// alignment patterns
+var QR_VERSIONS_M = []struct {
+	blocks    qrBlocks
+	alignment []int
+}{
+	{qrBlocks{1, 0, 16, 10}, nil},
+	{qrBlocks{1, 0, 28, 16}, []int{6, 18}},
+	{qrBlocks{1, 0, 44, 26}, []int{6, 22}},
+	{qrBlocks{2, 0, 32, 18}, []int{6, 26}},
+	{qrBlocks{2, 0, 43, 24}, []int{6, 30}},
+	{qrBlocks{4, 0, 27, 16}, []int{6, 34}},
+	{qrBlocks{4, 0, 31, 18}, []int{6, 22, 38}},
+	{qrBlocks{2, 2, 38, 22}, []int{6, 24, 42}},
+	{qrBlocks{3, 2, 36, 22}, []int{6, 26, 46}},
+	{qrBlocks{4, 1, 43, 26}, []int{6, 28, 50}},
+}
+
+func (b qrBlocks) dataCodewords() int {
+	return b.short*b.shortData + b.long*(b.shortData+1)
+}
+
+// qrMatrix is the grid of modules, true for dark, along with which of them
+// are the fixed patterns the data has to go around
+type qrMatrix struct {
+	size     int
+	dark     [][]bool
+	function [][]bool
+}
+
+func (m *qrMatrix) setFunction(x int, y int, dark bool) {
+	m.dark[y][x] = dark
+	m.function[y][x] = true
+}
+
+// encodeQR works out the smallest version that fits the data and the mask
+// that's easiest to scan
+func encodeQR(data []byte) (*qrMatrix, error) {
+	for version := 1; version <= len(QR_VERSIONS_M); version++ {
+		countBits := 8
+		if version >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= QR_VERSIONS_M[version-1].blocks.dataCodewords()*8 {
+			best, bestPenalty := (*qrMatrix)(nil), 0
+			for mask := 0; mask < 8; mask++ {
+				matrix := qrMatrixFor(data, version, mask)
+				if penalty := matrix.penalty(); best == nil || penalty < bestPenalty {
+					best, bestPenalty = matrix, penalty
+				}
+			}
+			return best, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%d bytes is too long for a QR code, %d is the most that fits", len(data), QR_VERSIONS_M[len(QR_VERSIONS_M)-1].blocks.dataCodewords()-3)
+}
+
+// qrMatrixFor lays the data out in the given version with the given mask
+func qrMatrixFor(data []byte, version int, mask int) *qrMatrix {
+	size := version*4 + 17
+	m := &qrMatrix{size: size, dark: make([][]bool, size), function: make([][]bool, size)}
+	for y := range m.dark {
+		m.dark[y] = make([]bool, size)
+		m.function[y] = make([]bool, size)
+	}
+	m.drawFunctionPatterns(version)
+	m.drawCodewords(qrCodewords(data, version))
+	m.applyMask(mask)
+	m.drawFormatBits(mask)
+
+	return m
+}
+
+// qrCodewords is the data in byte mode, padded out, with the error
+// correction added and everything interleaved the way it goes on the grid
+func qrCodewords(data []byte, version int) []byte {
+	blocks := QR_VERSIONS_M[version-1].blocks
+	capacity := blocks.dataCodewords()
+	bits := make([]bool, 0, capacity*8)
+	appendBits := func(value int, count int) {
+		for i := count - 1; i >= 0; i-- {
+			bits = append(bits, (value>>i)&1 == 1)
+		}
+	}
+	appendBits(0x4, 4)
+	if version >= 10 {
+		appendBits(len(data), 16)
+	} else {
+		appendBits(len(data), 8)
+	}
+	for _, b := range data {
+		appendBits(int(b), 8)
+	}
+	// Up to four zeros to end it, then out to a whole byte
+	for i := 0; i < 4 && len(bits) < capacity*8; i++ {
+		bits = append(bits, false)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+	codewords := make([]byte, 0, capacity)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 1 << (7 - j)
+			}
+		}
+		codewords = append(codewords, b)
+	}
+	for pad := byte(0xEC); len(codewords) < capacity; pad ^= 0xEC ^ 0x11 {
+		codewords = append(codewords, pad)
+	}
+
+	divisor := reedSolomonDivisor(blocks.ecc)
+	dataBlocks := make([][]byte, 0, blocks.short+blocks.long)
+	eccBlocks := make([][]byte, 0, blocks.short+blocks.long)
+	for i, start := 0, 0; i < blocks.short+blocks.long; i++ {
+		length := blocks.shortData
+		if i >= blocks.short {
+			length++
+		}
+		block := codewords[start : start+length]
+		start += length
+		dataBlocks = append(dataBlocks, block)
+		eccBlocks = append(eccBlocks, reedSolomonRemainder(block, divisor))
+	}
+	interleaved := make([]byte, 0, capacity+blocks.ecc*len(dataBlocks))
+	for i := 0; i <= blocks.shortData; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				interleaved = append(interleaved, block[i])
+			}
+		}
+	}
+	for i := 0; i < blocks.ecc; i++ {
+		for _, block := range eccBlocks {
+			interleaved = append(interleaved, block[i])
+		}
+	}
+
+	return interleaved
+}
+
+// gfMultiply multiplies in GF(2^8) over the QR code polynomial, 0x11D
+func gfMultiply(x byte, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= ((int(y) >> i) & 1) * int(x)
+	}
+
+	return byte(z)
+}
+
+// reedSolomonDivisor is the generator polynomial for degree codewords of
+// error correction, highest power first with the leading 1 left off
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+
+	return result
+}
+
+func reedSolomonRemainder(data []byte, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMultiply(divisor[i], factor)
+		}
+	}
+
+	return result
+}
+
+func (m *qrMatrix) drawFunctionPatterns(version int) {
+	for i := 0; i < m.size; i++ {
+		m.setFunction(6, i, i%2 == 0)
+		m.setFunction(i, 6, i%2 == 0)
+	}
+	m.drawFinder(3, 3)
+	m.drawFinder(m.size-4, 3)
+	m.drawFinder(3, m.size-4)
+	alignment := QR_VERSIONS_M[version-1].alignment
+	last := len(alignment) - 1
+	for i, x := range alignment {
+		for j, y := range alignment {
+			// The ones that would sit on a finder pattern are left out
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					m.setFunction(x+dx, y+dy, qrDistance(dx, dy) != 1)
+				}
+			}
+		}
+	}
+	// Holds the places for the format bits until the mask is picked
+	m.drawFormatBits(0)
+	if version >= 7 {
+		remainder := version
+		for i := 0; i < 12; i++ {
+			remainder = (remainder << 1) ^ ((remainder >> 11) * 0x1F25)
+		}
+		bits := version<<12 | remainder
+		for i := 0; i < 18; i++ {
+			dark := (bits>>i)&1 == 1
+			a, b := m.size-11+i%3, i/3
+			m.setFunction(a, b, dark)
+			m.setFunction(b, a, dark)
+		}
+	}
+}
+
+// drawFinder draws one of the big corner squares centered on x, y, along
+// with the light border around it
+func (m *qrMatrix) drawFinder(x int, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			if x+dx >= 0 && x+dx < m.size && y+dy >= 0 && y+dy < m.size {
+				distance := qrDistance(dx, dy)
+				m.setFunction(x+dx, y+dy, distance != 2 && distance != 4)
+			}
+		}
+	}
+}
+
+func qrDistance(dx int, dy int) int {
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	if dx > dy {
+		return dx
+	}
+
+	return dy
+}
+
+// drawFormatBits writes the error correction level and mask, twice over.
+// Level M is 00.
+func (m *qrMatrix) drawFormatBits(mask int) {
+	data := mask
+	remainder := data
+	for i := 0; i < 10; i++ {
+		remainder = (remainder << 1) ^ ((remainder >> 9) * 0x537)
+	}
+	bits := (data<<10 | remainder) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+	for i := 0; i <= 5; i++ {
+		m.setFunction(8, i, bit(i))
+	}
+	m.setFunction(8, 7, bit(6))
+	m.setFunction(8, 8, bit(7))
+	m.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.setFunction(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		m.setFunction(m.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.setFunction(8, m.size-15+i, bit(i))
+	}
+	// Always dark
+	m.setFunction(8, m.size-8, true)
+}
+
+// drawCodewords zigzags the codewords up and down two columns at a time
+// from the right, around the fixed patterns
+func (m *qrMatrix) drawCodewords(codewords []byte) {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		// The timing column is skipped over
+		if right == 6 {
+			right = 5
+		}
+		for vertical := 0; vertical < m.size; vertical++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vertical
+				if (right+1)&2 == 0 {
+					y = m.size - 1 - vertical
+				}
+				if !m.function[y][x] && i < len(codewords)*8 {
+					m.dark[y][x] = (codewords[i>>3]>>(7-i&7))&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (m *qrMatrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !m.function[y][x] {
+				m.dark[y][x] = !m.dark[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the code would be to scan, by the four rules in
+// the QR spec: long runs of one color, 2x2 blocks, things that look like a
+// finder pattern, and too much of one color overall
+func (m *qrMatrix) penalty() int {
+	penalty := 0
+	at := func(row bool, line int, i int) bool {
+		if row {
+			return m.dark[line][i]
+		}
+		return m.dark[i][line]
+	}
+	finderLike := []bool{true, false, true, true, true, false, true}
+	for _, row := range []bool{true, false} {
+		for line := 0; line < m.size; line++ {
+			run := 1
+			for i := 1; i < m.size; i++ {
+				if at(row, line, i) == at(row, line, i-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += run - 2
+				}
+				run = 1
+			}
+			if run >= 5 {
+				penalty += run - 2
+			}
+			for i := 0; i+7 <= m.size; i++ {
+				matches := true
+				for j, dark := range finderLike {
+					if at(row, line, i+j) != dark {
+						matches = false
+						break
+					}
+				}
+				if matches && (m.lightRun(row, line, i-4, i) || m.lightRun(row, line, i+7, i+11)) {
+					penalty += 40
+				}
+			}
+		}
+	}
+	darkCount := 0
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.dark[y][x] {
+				darkCount++
+			}
+			if x+1 < m.size && y+1 < m.size {
+				dark := m.dark[y][x]
+				if m.dark[y][x+1] == dark && m.dark[y+1][x] == dark && m.dark[y+1][x+1] == dark {
+					penalty += 3
+				}
+			}
+		}
+	}
+	// Every 5% away from half dark costs 10
+	percent := darkCount * 100 / (m.size * m.size)
+	if percent < 50 {
+		penalty += (50 - percent) / 5 * 10
+	} else {
+		penalty += (percent - 50) / 5 * 10
+	}
+
+	return penalty
+}
+
+// lightRun is whether modules from start up to end on the line are all
+// light, counting the quiet zone past the edges as light
+func (m *qrMatrix) lightRun(row bool, line int, start int, end int) bool {
+	for i := start; i < end; i++ {
+		if i < 0 || i >= m.size {
+			continue
+		}
+		if (row && m.dark[line][i]) || (!row && m.dark[i][line]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// QR_QUIET_ZONE is the light border the spec asks for, in modules
+const QR_QUIET_ZONE = 4
+
+// qrCodePNG draws the QR code for text at about pixels across
+func qrCodePNG(text string, pixels int) ([]byte, error) {
+	matrix, err := encodeQR([]byte(text))
+	if err != nil {
+		return nil, err
+	}
+	modules := matrix.size + 2*QR_QUIET_ZONE
+	scale := pixels / modules
+	if scale < 1 {
+		scale = 1
+	}
+	picture := image.NewGray(image.Rect(0, 0, modules*scale, modules*scale))
+	for y := 0; y < modules*scale; y++ {
+		for x := 0; x < modules*scale; x++ {
+			mx, my := x/scale-QR_QUIET_ZONE, y/scale-QR_QUIET_ZONE
+			shade := color.Gray{Y: 255}
+			if mx >= 0 && my >= 0 && mx < matrix.size && my < matrix.size && matrix.dark[my][mx] {
+				shade = color.Gray{Y: 0}
+			}
+			picture.SetGray(x, y, shade)
+		}
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, picture); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
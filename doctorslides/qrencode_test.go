@@ -0,0 +1,124 @@
+package doctorslides
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// Drawn by another QR library, so we know the layout, error correction and
+// masking line up with what a phone's scanner expects
+var EXAMPLE_COM_QR = []string{
+	"1111111010011100101111111",
+	"1000001011111100101000001",
+	"1011101000111111101011101",
+	"1011101010000111001011101",
+	"1011101000111111101011101",
+	"1000001001111110101000001",
+	"1111111010101010101111111",
+	"0000000011011001100000000",
+	"1011011101100101101001011",
+	"0100110010110100010100010",
+	"0100111000011100111110000",
+	"0000010111011000000001100",
+	"0111001000011011011010111",
+	"0100110001111011111110001",
+	"0101101001010100100010110",
+	"1001000100010011111110001",
+	"0001001000101011111111111",
+	"0000000010100010100010101",
+	"1111111010000110101010111",
+	"1000001010010111100010001",
+	"1011101000000110111111000",
+	"1011101010000001011011111",
+	"1011101010100010011010110",
+	"1000001001111111011010100",
+	"1111111011010000011111111",
+}
+
+func TestQRMatrix(t *testing.T) {
+	matrix := qrMatrixFor([]byte("https://example.com"), 2, 3)
+	if matrix.size != len(EXAMPLE_COM_QR) {
+		t.Fatalf("got a %d module QR code, want %d", matrix.size, len(EXAMPLE_COM_QR))
+	}
+	for y, want := range EXAMPLE_COM_QR {
+		var row strings.Builder
+		for x := 0; x < matrix.size; x++ {
+			if matrix.dark[y][x] {
+				row.WriteByte('1')
+			} else {
+				row.WriteByte('0')
+			}
+		}
+		if row.String() != want {
+			t.Errorf("row %d is %s, want %s", y, row.String(), want)
+		}
+	}
+}
+
+func TestEncodeQR(t *testing.T) {
+	matrix, err := encodeQR([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matrix.size != 21 {
+		t.Errorf("a short string should fit the smallest QR code, got %d modules", matrix.size)
+	}
+	if _, err := encodeQR(bytes.Repeat([]byte("a"), 214)); err == nil {
+		t.Errorf("214 bytes should be too long")
+	}
+}
+
+func TestQRCodePNG(t *testing.T) {
+	content, err := qrCodePNG("https://docs.google.com/document/d/abc123/edit", QR_CODE_PIXELS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	picture, err := png.Decode(bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("not a png: %s", err)
+	}
+	bounds := picture.Bounds()
+	if bounds.Dx() != bounds.Dy() || bounds.Dx() > QR_CODE_PIXELS {
+		t.Errorf("the QR code is %dx%d", bounds.Dx(), bounds.Dy())
+	}
+	// The quiet zone around the edge has to be light for scanners to find it
+	if r, _, _, _ := picture.At(0, 0).RGBA(); r != 0xffff {
+		t.Errorf("the corner of the QR code isn't light")
+	}
+}
+
+func TestQRCodeScopes(t *testing.T) {
+	useDefaultOptions(t)
+	options.command = "generate"
+	options.QRSource = true
+	if scopes := requiredScopes(); !isOneOf(SCOPE_DRIVE_FILE, scopes) || isOneOf(SCOPE_DRIVE, scopes) {
+		t.Errorf("--qr-source should only ask for drive.file, got %v", scopes)
+	}
+}
+
+func TestQRCodeIsRemovedAfterWriting(t *testing.T) {
+	useDefaultOptions(t)
+	options.QRURL = "https://example.com"
+	options.QRSource = true
+	writer := &fakeSlideWriter{}
+	removed := -1
+	restore := hostQRCode
+	hostQRCode = func(content []byte) (string, func(), error) {
+		return "https://drive.test/qr.png", func() { removed = len(writer.Requests) }, nil
+	}
+	t.Cleanup(func() { hostQRCode = restore })
+
+	writeSlides(writer, testDeckOutline())
+	found := false
+	for _, request := range writer.Requests {
+		found = found || (request.CreateImage != nil && request.CreateImage.Url == "https://drive.test/qr.png")
+	}
+	if !found {
+		t.Errorf("the closing slide has no QR code")
+	}
+	if removed != len(writer.Requests) {
+		t.Errorf("the QR code picture should only be removed once the slides are written")
+	}
+}
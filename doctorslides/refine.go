@@ -0,0 +1,43 @@
+package doctorslides
+
+import (
+	"fmt"
+)
+
+// refineOutline has GPT take a second pass over its own outline, tightening
+// it up against the document. The first outline is kept if the second pass
+// fails or comes back as something we can't parse.
+func refineOutline(content string, raw string, first GPTOutline) GPTOutline {
+	logln("Asking GPT to refine the outline")
+	template := `
+	Here is the outline of a slideshow made from the document below. Critique
+	it and then improve it: tighten up the bullet points, fix any titles that
+	don't say what the slide is about, and make sure nothing important from the
+	document is missing. %s
+
+	Give back only the improved outline in exactly the same format, starting
+	with the "{{SUBTITLE}}" line and with every slide between "{{SLIDE_START}}"
+	and "{{SLIDE_END}}" lines. Don't include the critique.
+
+	The outline:
+	%s
+
+	The document:
+	%s`
+	message := fmt.Sprintf(OUTLINE_FORMAT.fillIn(template), slideCountInstruction()+sectionInstruction()+footnoteInstruction()+checklistInstruction()+notesWordsInstruction()+languageInstruction(), raw, content)
+	refined, err := askGPT(message)
+	if err != nil {
+		logf("Could not refine the outline, keeping the first one: %s\n", err)
+		return first
+	}
+	outline := parseGPTOutline(refined)
+	if len(outline.Slides) == 0 {
+		logln("The refined outline was garbage, keeping the first one")
+		return first
+	}
+	if outline.Subtitle == "" {
+		outline.Subtitle = first.Subtitle
+	}
+
+	return outline
+}
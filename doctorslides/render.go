@@ -0,0 +1,60 @@
+package doctorslides
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	ANSI_RESET = "\033[0m"
+	ANSI_BOLD  = "\033[1m"
+	ANSI_DIM   = "\033[2m"
+	ANSI_CYAN  = "\033[36m"
+)
+
+// isTerminal is true when f is an actual terminal rather than a pipe or file,
+// which is the only time color codes are welcome
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// renderOutlineTerminal prints an outline so it's easy to look over before
+// committing to a deck
+func renderOutlineTerminal(w io.Writer, outline GPTOutline, color bool) {
+	paint := func(code string, text string) string {
+		if !color {
+			return text
+		}
+		return code + text + ANSI_RESET
+	}
+
+	fmt.Fprintln(w, paint(ANSI_BOLD, outline.Title))
+	if outline.Subtitle != "" {
+		fmt.Fprintln(w, outline.Subtitle)
+	}
+	for i, slide := range outline.Slides {
+		fmt.Fprintf(w, "\n%s %s\n", paint(ANSI_CYAN, fmt.Sprintf("%2d.", i+1)), paint(ANSI_BOLD, slide.Title))
+		for _, bullet := range slide.Bullets {
+			fmt.Fprintf(w, "    %s- %s\n", strings.Repeat("  ", bullet.Level), bullet.Text)
+		}
+		if slide.Image != "" {
+			fmt.Fprintf(w, "    %s\n", paint(ANSI_DIM, "Image: "+slide.Image))
+		}
+		if slide.Caption != "" {
+			fmt.Fprintf(w, "    %s\n", paint(ANSI_DIM, "Caption: "+slide.Caption))
+		}
+		if slide.Notes != "" {
+			fmt.Fprintf(w, "    %s\n", paint(ANSI_DIM, "Notes:"))
+			for _, line := range strings.Split(slide.Notes, "\n") {
+				fmt.Fprintf(w, "    %s\n", paint(ANSI_DIM, "  "+line))
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package doctorslides
+
+import (
+	"bufio"
+	"fmt"
+	"google.golang.org/api/drive/v3"
+	"os"
+	"strings"
+)
+
+const GOOGLE_SLIDES_MIME_TYPE = "application/vnd.google-apps.presentation"
+
+// driveQueryString quotes a value for a Drive search query
+func driveQueryString(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+
+	return "'" + value + "'"
+}
+
+// findPresentationsNamed looks for decks with exactly this title, in the
+// output folder when there is one and anywhere otherwise
+func findPresentationsNamed(title string) []*drive.File {
+	query := fmt.Sprintf("name = %s and mimeType = '%s' and trashed = false", driveQueryString(title), GOOGLE_SLIDES_MIME_TYPE)
+	if folderId := outputFolder(); folderId != "" {
+		query = query + fmt.Sprintf(" and %s in parents", driveQueryString(folderId))
+	}
+	call := getDriveService().Files.List().Q(query).
+		Fields("nextPageToken, files(id, name, modifiedTime, webViewLink)").
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true)
+	files := make([]*drive.File, 0)
+	err := call.Pages(CTX, func(page *drive.FileList) error {
+		files = append(files, page.Files...)
+		return nil
+	})
+	if err != nil {
+		logln("Could not search Drive for the old presentation")
+		panic(err)
+	}
+
+	return files
+}
+
+// replaceExistingPresentation trashes the deck a previous run made with the
+// same title, so there's only ever the one. It goes to the trash instead of
+// being deleted outright in case it was the wrong one. More than one match
+// is too ambiguous to guess at, so that stops the run.
+func replaceExistingPresentation(title string) {
+	matches := findPresentationsNamed(title)
+	if len(matches) == 0 {
+		return
+	}
+	if len(matches) > 1 {
+		lines := make([]string, 0)
+		for _, match := range matches {
+			lines = append(lines, fmt.Sprintf("  %s (modified %s): %s", match.Id, match.ModifiedTime, match.WebViewLink))
+		}
+		fatalf("There are %d presentations called \"%s\", so I don't know which one to replace:\n%s", len(matches), title, strings.Join(lines, "\n"))
+	}
+
+	old := matches[0]
+	if !options.Yes && !confirm(fmt.Sprintf("Replace the existing \"%s\" (%s)? It will be moved to the trash. [y/N] ", title, old.WebViewLink)) {
+		fatalf("Leaving the existing presentation alone.")
+	}
+	_, err := getDriveService().Files.Update(old.Id, &drive.File{Trashed: true}).SupportsAllDrives(true).Context(CTX).Do()
+	if err != nil {
+		logln("Could not move the old presentation to the trash")
+		panic(err)
+	}
+	logf("Moved the old presentation to the trash: %s\n", old.Id)
+}
+
+// confirm asks a yes or no question on the terminal. Anything but a yes
+// counts as a no, including there being nobody there to answer.
+func confirm(question string) bool {
+	fmt.Fprint(os.Stderr, question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
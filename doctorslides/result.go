@@ -0,0 +1,107 @@
+package doctorslides
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/sashabaranov/go-openai"
+	"os"
+	"sync"
+	"time"
+)
+
+// RunResult is what --json-result prints once a deck is finished
+type RunResult struct {
+	PresentationId   string  `json:"presentationId"`
+	URL              string  `json:"url"`
+	SpeakerDocURL    string  `json:"speakerDocUrl,omitempty"`
+	SlideCount       int     `json:"slideCount"`
+	Model            string  `json:"model"`
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+	TotalTokens      int     `json:"totalTokens"`
+	EstimatedCost    float64 `json:"estimatedCost"`
+	ElapsedSeconds   float64 `json:"elapsedSeconds"`
+}
+
+var (
+	START_TIME = time.Now()
+	// USAGE adds up the tokens from every call we make to OpenAI, retries
+	// included
+	USAGE      openai.Usage
+	usageMutex sync.Mutex
+)
+
+func recordUsage(usage openai.Usage) {
+	usageMutex.Lock()
+	USAGE.PromptTokens += usage.PromptTokens
+	USAGE.CompletionTokens += usage.CompletionTokens
+	USAGE.TotalTokens += usage.TotalTokens
+	usageMutex.Unlock()
+	checkBudget()
+}
+
+// What GPT_MODEL costs in US dollars per thousand tokens
+const (
+	PROMPT_COST_PER_1K     = 0.0015
+	COMPLETION_COST_PER_1K = 0.002
+)
+
+func usageCost(usage openai.Usage) float64 {
+	return float64(usage.PromptTokens)/1000*PROMPT_COST_PER_1K +
+		float64(usage.CompletionTokens)/1000*COMPLETION_COST_PER_1K
+}
+
+// checkBudget stops everything once --max-tokens-budget or --max-cost has
+// been spent. It gets called after every call to OpenAI and before the big
+// ones, so a run that's already over doesn't go on to spend more.
+func checkBudget() {
+	usageMutex.Lock()
+	usage := USAGE
+	usageMutex.Unlock()
+	cost := usageCost(usage)
+	overTokens := options.MaxTokensBudget > 0 && usage.TotalTokens >= options.MaxTokensBudget
+	overCost := options.MaxCost > 0 && cost >= options.MaxCost
+	if overTokens || overCost {
+		fatalf("Stopping here, the OpenAI budget is used up: %d tokens so far (about $%.4f)", usage.TotalTokens, cost)
+	}
+}
+
+func printJSONResult(presentationId string, url string, speakerDocURL string, slideCount int) {
+	printJSON(RunResult{
+		PresentationId:   presentationId,
+		URL:              url,
+		SpeakerDocURL:    speakerDocURL,
+		SlideCount:       slideCount,
+		Model:            GPT_MODEL,
+		PromptTokens:     USAGE.PromptTokens,
+		CompletionTokens: USAGE.CompletionTokens,
+		TotalTokens:      USAGE.TotalTokens,
+		EstimatedCost:    usageCost(USAGE),
+		ElapsedSeconds:   time.Since(START_TIME).Seconds(),
+	})
+}
+
+func printJSONError(message string) {
+	printJSON(map[string]string{"error": message})
+}
+
+func printJSON(v any) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(encoded))
+}
+
+// reportPanicAsJSON is deferred by commands that support --json-result, so
+// that even the failures we don't handle nicely come out as JSON
+func reportPanicAsJSON() {
+	if !options.JSONResult {
+		return
+	}
+	if r := recover(); r != nil {
+		logln(r)
+		printJSONError(fmt.Sprint(r))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,86 @@
+package doctorslides
+
+import (
+	"errors"
+)
+
+const (
+	SCOPE_DOCUMENTS_READONLY = "https://www.googleapis.com/auth/documents.readonly"
+	SCOPE_DOCUMENTS          = "https://www.googleapis.com/auth/documents"
+	SCOPE_PRESENTATIONS      = "https://www.googleapis.com/auth/presentations"
+	SCOPE_DRIVE_READONLY     = "https://www.googleapis.com/auth/drive.readonly"
+	SCOPE_DRIVE_FILE         = "https://www.googleapis.com/auth/drive.file"
+	SCOPE_DRIVE              = "https://www.googleapis.com/auth/drive"
+)
+
+// errScopeMismatch is for a cached token that was granted fewer scopes than
+// what we're about to do needs
+var errScopeMismatch = errors.New("the saved token doesn't have the scopes this needs")
+
+// requiredScopes works out the smallest set of Google scopes the chosen mode
+// needs, so we aren't asking for the keys to everyone's Drive just to read a
+// doc.
+func requiredScopes() []string {
+	if options.command == "list-layouts" {
+		return []string{SCOPE_PRESENTATIONS}
+	}
+	scopes := make([]string, 0)
+	readsDoc := options.FromOutline == "" && !options.readsText
+	exportsLocally := options.command == "export"
+	// --target onedrive builds its pptx without Google
+	writesSlides := options.WriteOutline == "" && !options.DryRun && !exportsLocally && options.Target != "onedrive"
+	usesDrive := options.ReadMode == "export" || options.FromFolder != ""
+	// Moving the deck into someone else's folder or a shared drive, trashing
+	// the old one, or uploading a .pptx to start from are the things that
+	// need write access to Drive
+	movesDeck := writesSlides && (outputFolder() != "" || options.ReplaceExisting || options.ImportPptx != "")
+	writesDoc := writesSlides && options.SpeakerDoc
+	// The QR code picture goes up to Drive for a moment so Slides can fetch
+	// it, and drive.file only reaches the files we make ourselves
+	hostsQRCode := writesSlides && options.QRSource
+
+	if writesDoc {
+		scopes = append(scopes, SCOPE_DOCUMENTS)
+	} else if readsDoc {
+		scopes = append(scopes, SCOPE_DOCUMENTS_READONLY)
+	}
+	if writesSlides {
+		scopes = append(scopes, SCOPE_PRESENTATIONS)
+	}
+	if movesDeck {
+		scopes = append(scopes, SCOPE_DRIVE)
+	} else {
+		if usesDrive {
+			scopes = append(scopes, SCOPE_DRIVE_READONLY)
+		}
+		if hostsQRCode {
+			scopes = append(scopes, SCOPE_DRIVE_FILE)
+		}
+	}
+
+	return scopes
+}
+
+func hasScopes(granted []string, needed []string) bool {
+	for _, scope := range needed {
+		if !isOneOf(scope, granted) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mergeScopes is used when signing in again so the new token still covers
+// whatever the old one did, otherwise switching between modes would mean
+// signing in every single time
+func mergeScopes(a []string, b []string) []string {
+	merged := make([]string, 0)
+	for _, scope := range append(append([]string{}, a...), b...) {
+		if !isOneOf(scope, merged) {
+			merged = append(merged, scope)
+		}
+	}
+
+	return merged
+}
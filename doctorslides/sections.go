@@ -0,0 +1,67 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/docs/v1"
+	"strings"
+)
+
+// SECTION_MARKER goes in front of each top level heading for
+// --split-on-headings, so GPT can tell where one section stops and the next
+// starts
+const SECTION_MARKER = "### SECTION: "
+
+// sectionText is a paragraph's text the way GPT should see it, with the
+// heading of a new section marked
+func sectionText(paragraph *docs.Paragraph) string {
+	text := paragraphText(paragraph)
+	if !options.SplitOnHeadings {
+		return text
+	}
+	if level, isHeading := headingLevel(paragraph); isHeading && level == 1 {
+		return SECTION_MARKER + text
+	}
+
+	return text
+}
+
+// countSections is how many sections the text ended up marked with
+func countSections(text string) int {
+	count := 0
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, SECTION_MARKER) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func sectionInstruction() string {
+	if !options.SplitOnHeadings {
+		return ""
+	}
+
+	instruction := fmt.Sprintf(" Each line starting with \"%s\" begins a section of the document. Start a new slide at each section, and never put two sections on the same slide.", strings.TrimSpace(SECTION_MARKER))
+	if options.ColorSections {
+		instruction += fmt.Sprintf(" Give every slide a \"%s\" line with the heading of the section it comes from.", OUTLINE_FORMAT.Section)
+	}
+
+	return instruction
+}
+
+// checkSections lets you know when GPT didn't keep to the sections, since
+// that's the one thing --split-on-headings is for
+func checkSections(textContent string, outline GPTOutline) {
+	if !options.SplitOnHeadings {
+		return
+	}
+	sections := countSections(textContent)
+	if sections == 0 {
+		logln("--split-on-headings didn't find any Heading 1s in the document to split on.")
+		return
+	}
+	if len(outline.Slides) < sections {
+		logf("WARNING: The document has %d sections but GPT only made %d slides, so some sections got put together.\n", sections, len(outline.Slides))
+	}
+}
@@ -0,0 +1,163 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/slides/v1"
+)
+
+// SLIDE_LAYOUT_FIELDS is as much of the deck as writeSlides needs once the
+// slides are made: the page size, where each placeholder and notes box is,
+// and the masters for the theme. The size and position are for lining
+// captions up under a picture placeholder, and the text's start is so a
+// footer placeholder that already says something gets cleared before we
+// write in it. The whole deck is a lot more than that, mostly layouts and
+// their styling, and it grows with every slide.
+const SLIDE_LAYOUT_FIELDS googleapi.Field = "presentationId,pageSize,masters(objectId),slides(objectId,pageElements(objectId,size,transform,shape(placeholder,text(textElements(startIndex))),image(placeholder)),slideProperties(notesPage(notesProperties)))"
+
+// SLIDE_TEXT_FIELDS adds the text on each slide to that, for the theme fonts
+const SLIDE_TEXT_FIELDS googleapi.Field = "presentationId,slides(objectId,pageElements(objectId,shape(placeholder,text(textElements(startIndex)))))"
+
+// SlideWriter is everything writeSlides needs from the Slides API, so a deck
+// can be built against something other than the real thing
+type SlideWriter interface {
+	Create(presentation *slides.Presentation) (*slides.Presentation, error)
+	BatchUpdate(presentationId string, updates *slides.BatchUpdatePresentationRequest) (*slides.BatchUpdatePresentationResponse, error)
+	// Get only fills in the given fields, or everything if there aren't any
+	Get(presentationId string, fields ...googleapi.Field) (*slides.Presentation, error)
+}
+
+type liveSlideWriter struct {
+	service *slides.Service
+}
+
+func (w liveSlideWriter) Create(presentation *slides.Presentation) (*slides.Presentation, error) {
+	return w.service.Presentations.Create(presentation).Context(CTX).Do()
+}
+
+func (w liveSlideWriter) BatchUpdate(presentationId string, updates *slides.BatchUpdatePresentationRequest) (*slides.BatchUpdatePresentationResponse, error) {
+	return w.service.Presentations.BatchUpdate(presentationId, updates).Context(CTX).Do()
+}
+
+func (w liveSlideWriter) Get(presentationId string, fields ...googleapi.Field) (*slides.Presentation, error) {
+	call := w.service.Presentations.Get(presentationId)
+	if len(fields) > 0 {
+		call = call.Fields(fields...)
+	}
+
+	return call.Context(CTX).Do()
+}
+
+// fakeSlideWriter keeps a deck in memory and records every request sent to
+// it. It only does as much as writeSlides needs: slides added after the
+// first come out empty apart from their notes page, so every text box on
+// them gets made from scratch.
+type fakeSlideWriter struct {
+	presentation *slides.Presentation
+	Requests     []*slides.Request
+	nextId       int
+}
+
+func newFakeSlideWriter() *fakeSlideWriter {
+	return &fakeSlideWriter{}
+}
+
+func (w *fakeSlideWriter) newPage() *slides.Page {
+	w.nextId++
+	id := fmt.Sprintf("fake_slide_%d", w.nextId)
+
+	return &slides.Page{
+		ObjectId: id,
+		SlideProperties: &slides.SlideProperties{
+			NotesPage: &slides.Page{
+				NotesProperties: &slides.NotesProperties{
+					SpeakerNotesObjectId: id + "_notes",
+				},
+			},
+		},
+	}
+}
+
+// Create starts the deck off the way Slides does, with a single TITLE slide
+// that has its title and subtitle placeholders
+func (w *fakeSlideWriter) Create(presentation *slides.Presentation) (*slides.Presentation, error) {
+	title := w.newPage()
+	for _, placeholderType := range []string{"CENTERED_TITLE", "SUBTITLE"} {
+		title.PageElements = append(title.PageElements, &slides.PageElement{
+			ObjectId: title.ObjectId + "_" + placeholderType,
+			Shape: &slides.Shape{
+				Placeholder: &slides.Placeholder{Type: placeholderType},
+			},
+		})
+	}
+	w.presentation = &slides.Presentation{
+		PresentationId: "fake_presentation",
+		Title:          presentation.Title,
+		PageSize:       presentation.PageSize,
+		Slides:         []*slides.Page{title},
+	}
+
+	return w.presentation, nil
+}
+
+func (w *fakeSlideWriter) BatchUpdate(presentationId string, updates *slides.BatchUpdatePresentationRequest) (*slides.BatchUpdatePresentationResponse, error) {
+	if w.presentation == nil || presentationId != w.presentation.PresentationId {
+		return nil, fmt.Errorf("there's no presentation %s", presentationId)
+	}
+	for _, request := range updates.Requests {
+		w.Requests = append(w.Requests, request)
+		if request.CreateSlide != nil {
+			index := int(request.CreateSlide.InsertionIndex)
+			if index == 0 && !isOneOf("InsertionIndex", request.CreateSlide.ForceSendFields) {
+				index = len(w.presentation.Slides)
+			}
+			if index > len(w.presentation.Slides) {
+				index = len(w.presentation.Slides)
+			}
+			w.presentation.Slides = append(w.presentation.Slides[:index], append([]*slides.Page{w.newPage()}, w.presentation.Slides[index:]...)...)
+		}
+		if request.DeleteObject != nil {
+			for i, slide := range w.presentation.Slides {
+				if slide.ObjectId == request.DeleteObject.ObjectId {
+					w.presentation.Slides = append(w.presentation.Slides[:i], w.presentation.Slides[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+
+	return &slides.BatchUpdatePresentationResponse{PresentationId: presentationId}, nil
+}
+
+// Get ignores the fields, the fake deck never has anything extra in it
+func (w *fakeSlideWriter) Get(presentationId string, fields ...googleapi.Field) (*slides.Presentation, error) {
+	if w.presentation == nil || presentationId != w.presentation.PresentationId {
+		return nil, fmt.Errorf("there's no presentation %s", presentationId)
+	}
+
+	return w.presentation, nil
+}
+
+// insertedText gives back the text of every InsertText the deck got, in the
+// order they were sent
+func (w *fakeSlideWriter) insertedText() []string {
+	texts := make([]string, 0)
+	for _, request := range w.Requests {
+		if request.InsertText != nil {
+			texts = append(texts, request.InsertText.Text)
+		}
+	}
+
+	return texts
+}
+
+func (w *fakeSlideWriter) createdSlides() int {
+	count := 0
+	for _, request := range w.Requests {
+		if request.CreateSlide != nil {
+			count++
+		}
+	}
+
+	return count
+}
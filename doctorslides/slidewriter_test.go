@@ -0,0 +1,367 @@
+package doctorslides
+
+import (
+	"encoding/json"
+	"fmt"
+	"google.golang.org/api/slides/v1"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// describeRequest sums a request up in one line, with the parts that say
+// where things went in full and everything else by its kind
+func describeRequest(request *slides.Request) string {
+	switch {
+	case request.CreateSlide != nil:
+		return fmt.Sprintf("CreateSlide %s", request.CreateSlide.SlideLayoutReference.PredefinedLayout)
+	case request.InsertText != nil:
+		return fmt.Sprintf("InsertText %s %q", request.InsertText.ObjectId, request.InsertText.Text)
+	case request.DeleteObject != nil:
+		return fmt.Sprintf("DeleteObject %s", request.DeleteObject.ObjectId)
+	case request.CreateShape != nil:
+		return fmt.Sprintf("CreateShape %s %s on %s", request.CreateShape.ShapeType, request.CreateShape.ObjectId, request.CreateShape.ElementProperties.PageObjectId)
+	}
+	value := reflect.ValueOf(*request)
+	for i := 0; i < value.NumField(); i++ {
+		if field := value.Field(i); field.Kind() == reflect.Ptr && !field.IsNil() {
+			return value.Type().Field(i).Name
+		}
+	}
+
+	return "empty request"
+}
+
+func describeRequests(requests []*slides.Request) []string {
+	descriptions := make([]string, 0, len(requests))
+	for _, request := range requests {
+		descriptions = append(descriptions, describeRequest(request))
+	}
+
+	return descriptions
+}
+
+func testDeckOutline() GPTOutline {
+	return GPTOutline{
+		Title: "Test Deck",
+		Slides: []SimpleSlide{
+			{Title: "First", Bullets: []Bullet{{Text: "One"}, {Text: "Two"}}, Notes: "Say hello"},
+			{Title: "Second", Bullets: []Bullet{{Text: "Three"}}},
+		},
+	}
+}
+
+func TestWriteSlidesRequests(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func()
+		want  []string
+	}{
+		{
+			name:  "default",
+			setup: func() {},
+			want: []string{
+				"CreateSlide TITLE_AND_BODY",
+				"CreateSlide TITLE_AND_BODY",
+				"CreateSlide TITLE",
+				`InsertText fake_slide_1_CENTERED_TITLE "Test Deck"`,
+				"CreateShape TEXT_BOX fake_slide_2_title on fake_slide_2",
+				"CreateShape TEXT_BOX fake_slide_2_body on fake_slide_2",
+				`InsertText fake_slide_2_title "First"`,
+				`InsertText fake_slide_2_body "One\nTwo"`,
+				"CreateParagraphBullets",
+				`InsertText fake_slide_2_notes "Say hello"`,
+				"CreateShape TEXT_BOX fake_slide_3_title on fake_slide_3",
+				"CreateShape TEXT_BOX fake_slide_3_body on fake_slide_3",
+				`InsertText fake_slide_3_title "Second"`,
+				`InsertText fake_slide_3_body "Three"`,
+				"CreateParagraphBullets",
+				"CreateShape TEXT_BOX fake_slide_4_title on fake_slide_4",
+				`InsertText fake_slide_4_title "The End"`,
+			},
+		},
+		{
+			name:  "no title slide",
+			setup: func() { options.NoTitleSlide = true },
+			want: []string{
+				"CreateSlide TITLE_AND_BODY",
+				"CreateSlide TITLE_AND_BODY",
+				"CreateSlide TITLE",
+				"DeleteObject fake_slide_1",
+				"CreateShape TEXT_BOX fake_slide_2_title on fake_slide_2",
+				"CreateShape TEXT_BOX fake_slide_2_body on fake_slide_2",
+				`InsertText fake_slide_2_title "First"`,
+				`InsertText fake_slide_2_body "One\nTwo"`,
+				"CreateParagraphBullets",
+				`InsertText fake_slide_2_notes "Say hello"`,
+				"CreateShape TEXT_BOX fake_slide_3_title on fake_slide_3",
+				"CreateShape TEXT_BOX fake_slide_3_body on fake_slide_3",
+				`InsertText fake_slide_3_title "Second"`,
+				`InsertText fake_slide_3_body "Three"`,
+				"CreateParagraphBullets",
+				"CreateShape TEXT_BOX fake_slide_4_title on fake_slide_4",
+				`InsertText fake_slide_4_title "The End"`,
+			},
+		},
+		{
+			name:  "agenda",
+			setup: func() { options.Agenda = true },
+			want: []string{
+				"CreateSlide TITLE_AND_BODY",
+				"CreateSlide TITLE_AND_BODY",
+				"CreateSlide TITLE_AND_BODY",
+				"CreateSlide TITLE",
+				`InsertText fake_slide_1_CENTERED_TITLE "Test Deck"`,
+				"CreateShape TEXT_BOX fake_slide_2_title on fake_slide_2",
+				"CreateShape TEXT_BOX fake_slide_2_body on fake_slide_2",
+				`InsertText fake_slide_2_title "Agenda"`,
+				`InsertText fake_slide_2_body "First\nSecond"`,
+				"CreateParagraphBullets",
+				"UpdateTextStyle",
+				"UpdateTextStyle",
+				"CreateShape TEXT_BOX fake_slide_3_title on fake_slide_3",
+				"CreateShape TEXT_BOX fake_slide_3_body on fake_slide_3",
+				`InsertText fake_slide_3_title "First"`,
+				`InsertText fake_slide_3_body "One\nTwo"`,
+				"CreateParagraphBullets",
+				`InsertText fake_slide_3_notes "Say hello"`,
+				"CreateShape TEXT_BOX fake_slide_4_title on fake_slide_4",
+				"CreateShape TEXT_BOX fake_slide_4_body on fake_slide_4",
+				`InsertText fake_slide_4_title "Second"`,
+				`InsertText fake_slide_4_body "Three"`,
+				"CreateParagraphBullets",
+				"CreateShape TEXT_BOX fake_slide_5_title on fake_slide_5",
+				`InsertText fake_slide_5_title "The End"`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useDefaultOptions(t)
+			test.setup()
+			writer := &fakeSlideWriter{}
+			writeSlides(writer, testDeckOutline())
+			got := describeRequests(writer.Requests)
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("requests were:\n%s\n\nwant:\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
+
+// fieldMask is a parsed Google API field mask like "a,b(c,d)". A field with
+// no children of its own is kept whole.
+type fieldMask map[string]fieldMask
+
+func parseFieldMask(mask string) (fieldMask, string) {
+	fields := fieldMask{}
+	for mask != "" {
+		end := strings.IndexAny(mask, ",()")
+		if end < 0 {
+			end = len(mask)
+		}
+		name := mask[:end]
+		mask = mask[end:]
+		var children fieldMask
+		if strings.HasPrefix(mask, "(") {
+			children, mask = parseFieldMask(mask[1:])
+			mask = strings.TrimPrefix(mask, ")")
+		}
+		fields[name] = children
+		if strings.HasPrefix(mask, ")") {
+			break
+		}
+		mask = strings.TrimPrefix(mask, ",")
+	}
+
+	return fields, mask
+}
+
+// pruneFields does to decoded JSON what the API does with a field mask
+func pruneFields(value any, mask fieldMask) any {
+	switch v := value.(type) {
+	case map[string]any:
+		pruned := map[string]any{}
+		for name, children := range mask {
+			if field, ok := v[name]; ok {
+				if children == nil {
+					pruned[name] = field
+				} else {
+					pruned[name] = pruneFields(field, children)
+				}
+			}
+		}
+		return pruned
+	case []any:
+		pruned := make([]any, len(v))
+		for i := range v {
+			pruned[i] = pruneFields(v[i], mask)
+		}
+		return pruned
+	}
+
+	return value
+}
+
+func styledShape(id string, placeholder string, text string) *slides.PageElement {
+	style := &slides.TextStyle{
+		FontFamily:      "Arial",
+		FontSize:        &slides.Dimension{Magnitude: 18, Unit: "PT"},
+		ForegroundColor: &slides.OptionalColor{OpaqueColor: &slides.OpaqueColor{ThemeColor: "DARK1"}},
+	}
+
+	return &slides.PageElement{
+		ObjectId:  id,
+		Size:      &slides.Size{Width: &slides.Dimension{Magnitude: 8000000, Unit: "EMU"}, Height: &slides.Dimension{Magnitude: 1000000, Unit: "EMU"}},
+		Transform: &slides.AffineTransform{ScaleX: 1, ScaleY: 1, TranslateX: 300000, TranslateY: 200000, Unit: "EMU"},
+		Shape: &slides.Shape{
+			ShapeType:       "TEXT_BOX",
+			Placeholder:     &slides.Placeholder{Type: placeholder, ParentObjectId: "layout_" + placeholder},
+			ShapeProperties: &slides.ShapeProperties{ContentAlignment: "TOP", Outline: &slides.Outline{DashStyle: "SOLID", PropertyState: "NOT_RENDERED"}},
+			Text: &slides.TextContent{TextElements: []*slides.TextElement{
+				{EndIndex: int64(len(text)), ParagraphMarker: &slides.ParagraphMarker{Style: &slides.ParagraphStyle{Alignment: "START", Direction: "LEFT_TO_RIGHT", LineSpacing: 100}}},
+				{EndIndex: int64(len(text)), TextRun: &slides.TextRun{Content: text, Style: style}},
+			}},
+		},
+	}
+}
+
+// manySlidePresentation is about what Get hands back for a deck of that
+// many slides, layouts and masters included
+func manySlidePresentation(count int) *slides.Presentation {
+	presentation := &slides.Presentation{PresentationId: "many_slides", Title: "Many Slides"}
+	for i := 0; i < 11; i++ {
+		presentation.Layouts = append(presentation.Layouts, &slides.Page{
+			ObjectId:         fmt.Sprintf("layout_%d", i),
+			LayoutProperties: &slides.LayoutProperties{Name: fmt.Sprintf("LAYOUT_%d", i), DisplayName: "Layout"},
+			PageElements:     []*slides.PageElement{styledShape(fmt.Sprintf("layout_%d_title", i), "TITLE", "Click to add title"), styledShape(fmt.Sprintf("layout_%d_body", i), "BODY", "Click to add text")},
+		})
+	}
+	presentation.Masters = []*slides.Page{{ObjectId: "master", PageElements: []*slides.PageElement{styledShape("master_title", "TITLE", "Title")}}}
+	for i := 0; i < count; i++ {
+		id := fmt.Sprintf("slide_%d", i)
+		presentation.Slides = append(presentation.Slides, &slides.Page{
+			ObjectId: id,
+			PageElements: []*slides.PageElement{
+				styledShape(id+"_title", "TITLE", fmt.Sprintf("Slide %d", i)),
+				styledShape(id+"_body", "BODY", "First point\nSecond point\nThird point"),
+			},
+			SlideProperties: &slides.SlideProperties{
+				LayoutObjectId: "layout_1",
+				MasterObjectId: "master",
+				NotesPage: &slides.Page{
+					ObjectId:        id + "_notes_page",
+					NotesProperties: &slides.NotesProperties{SpeakerNotesObjectId: id + "_notes"},
+					PageElements:    []*slides.PageElement{styledShape(id+"_notes", "BODY", "What to say about it")},
+				},
+			},
+		})
+	}
+
+	return presentation
+}
+
+// fieldMaskSizes is how big the deck is as JSON in full and cut down to
+// the mask
+func fieldMaskSizes(t testing.TB, presentation *slides.Presentation, mask string) (int, int) {
+	full, err := json.Marshal(presentation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded any
+	if err := json.Unmarshal(full, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	fields, _ := parseFieldMask(mask)
+	pruned, err := json.Marshal(pruneFields(decoded, fields))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return len(full), len(pruned)
+}
+
+func TestSlideLayoutFieldsShrinkTheDeck(t *testing.T) {
+	full, pruned := fieldMaskSizes(t, manySlidePresentation(100), string(SLIDE_LAYOUT_FIELDS))
+	t.Logf("100 slides: %d bytes in full, %d with SLIDE_LAYOUT_FIELDS (%.0f%%)", full, pruned, 100*float64(pruned)/float64(full))
+	if pruned*3 > full {
+		t.Errorf("the field mask only cut the deck from %d bytes to %d", full, pruned)
+	}
+
+	// What writeSlides reads has to make it through the mask
+	presentation, encoded := maskedPresentation(manySlidePresentation(1), string(SLIDE_LAYOUT_FIELDS))
+	slide := presentation.Slides[0]
+	if slide.PageElements[0].Shape.Placeholder.Type != "TITLE" || slide.PageElements[0].Size == nil {
+		t.Errorf("the placeholders didn't survive the mask: %s", encoded)
+	}
+	if slide.SlideProperties.NotesPage.NotesProperties.SpeakerNotesObjectId != "slide_0_notes" {
+		t.Errorf("the notes box didn't survive the mask: %s", encoded)
+	}
+	// A footer placeholder that already has text has to be cleared first
+	if len(buildClearTextRequests(slide.PageElements[1])) == 0 {
+		t.Errorf("the placeholder text didn't survive the mask: %s", encoded)
+	}
+}
+
+func TestThumbnailFieldsKeepTheTitle(t *testing.T) {
+	presentation, encoded := maskedPresentation(manySlidePresentation(1), THUMBNAIL_FIELDS)
+	title, _ := findTextBox(presentation.Slides[0], "title", "TITLE", "CENTERED_TITLE")
+	if got := shapeText(title); got != "Slide 0" {
+		t.Errorf("the thumbnail would be named for %q, the mask left: %s", got, encoded)
+	}
+}
+
+// maskedPresentation is the deck as the API would send it back for mask
+func maskedPresentation(presentation *slides.Presentation, mask string) (slides.Presentation, []byte) {
+	fields, _ := parseFieldMask(mask)
+	var decoded map[string]any
+	encoded, _ := json.Marshal(presentation)
+	json.Unmarshal(encoded, &decoded)
+	encoded, _ = json.Marshal(pruneFields(decoded, fields))
+	var masked slides.Presentation
+	json.Unmarshal(encoded, &masked)
+
+	return masked, encoded
+}
+
+func BenchmarkSlideLayoutFields(b *testing.B) {
+	presentation := manySlidePresentation(100)
+	var full, pruned int
+	for i := 0; i < b.N; i++ {
+		full, pruned = fieldMaskSizes(b, presentation, string(SLIDE_LAYOUT_FIELDS))
+	}
+	b.ReportMetric(float64(full), "full-bytes")
+	b.ReportMetric(float64(pruned), "masked-bytes")
+}
+
+// otherSizeWriter makes its deck 4:3, whatever size it was asked for, the
+// way a deck started from an imported pptx keeps the pptx's size
+type otherSizeWriter struct {
+	*fakeSlideWriter
+}
+
+func (w otherSizeWriter) Create(presentation *slides.Presentation) (*slides.Presentation, error) {
+	created, err := w.fakeSlideWriter.Create(presentation)
+	if err == nil {
+		created.PageSize = &slides.Size{Width: &slides.Dimension{Magnitude: 720, Unit: "PT"}, Height: &slides.Dimension{Magnitude: 540, Unit: "PT"}}
+	}
+
+	return created, err
+}
+
+func TestWriteSlidesUsesTheDecksPageSize(t *testing.T) {
+	useDefaultOptions(t)
+	writer := otherSizeWriter{&fakeSlideWriter{}}
+	writeSlides(writer, testDeckOutline())
+	for _, request := range writer.Requests {
+		if request.CreateShape == nil || request.CreateShape.ObjectId != "fake_slide_2_body" {
+			continue
+		}
+		properties := request.CreateShape.ElementProperties
+		if properties.Size.Height.Magnitude != BODY_BOX.Height*4/3 || properties.Transform.TranslateY != BODY_BOX.Y*4/3 {
+			t.Errorf("the body box wasn't fitted to the 4:3 page: %+v %+v", properties.Size.Height, properties.Transform)
+		}
+		return
+	}
+	t.Errorf("no body box was made")
+}
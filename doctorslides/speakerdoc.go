@@ -0,0 +1,97 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/docs/v1"
+	"strings"
+	"unicode/utf16"
+)
+
+// writeSpeakerDoc makes a Google Doc to go along with the deck, with a heading
+// for every slide and its speaker notes underneath, and returns the doc's URL.
+func writeSpeakerDoc(outline GPTOutline) string {
+	logln("Writing the speaker notes doc")
+	docsService := getDocsService()
+	doc, err := docsService.Documents.Create(&docs.Document{
+		Title: fmt.Sprintf("%s (speaker notes)", outline.Title),
+	}).Context(CTX).Do()
+	if err != nil {
+		logln("Could not create the speaker notes doc")
+		panic(err)
+	}
+
+	updates := docs.BatchUpdateDocumentRequest{Requests: buildSpeakerDocRequests(outline)}
+	_, err = docsService.Documents.BatchUpdate(doc.DocumentId, &updates).Context(CTX).Do()
+	if err != nil {
+		logln("Could not write the speaker notes doc")
+		panic(err)
+	}
+
+	url := documentURL(doc.DocumentId)
+	logf("Speaker notes: %s\n", url)
+
+	return url
+}
+
+// speakerDocNotes is what goes under a slide's heading. Slides without notes
+// get their bullets instead so the presenter still has something to go on.
+func speakerDocNotes(slide SimpleSlide) string {
+	if strings.TrimSpace(slide.Notes) != "" {
+		return strings.TrimSpace(slide.Notes)
+	}
+	if len(slide.Bullets) > 0 {
+		return strings.Join(bulletTexts(slide.Bullets), "\n")
+	}
+
+	return "No notes for this slide."
+}
+
+// buildSpeakerDocRequests puts all the text in with one insert and then goes
+// back to style the headings. Docs counts positions in UTF-16 code units, and
+// a new doc's body starts at index 1.
+func buildSpeakerDocRequests(outline GPTOutline) []*docs.Request {
+	var b strings.Builder
+	headings := make([]*docs.Range, 0)
+	position := int64(1)
+	write := func(text string) {
+		b.WriteString(text)
+		position += int64(len(utf16.Encode([]rune(text))))
+	}
+
+	start := position
+	write(outline.Title + "\n")
+	titleRange := &docs.Range{StartIndex: start, EndIndex: position}
+	for i, slide := range outline.Slides {
+		start := position
+		write(fmt.Sprintf("%d. %s\n", i+1, slide.Title))
+		headings = append(headings, &docs.Range{StartIndex: start, EndIndex: position})
+		write(speakerDocNotes(slide) + "\n")
+	}
+
+	requests := []*docs.Request{
+		{
+			InsertText: &docs.InsertTextRequest{
+				Location: &docs.Location{Index: 1},
+				Text:     b.String(),
+			},
+		},
+		{
+			UpdateParagraphStyle: &docs.UpdateParagraphStyleRequest{
+				Range:          titleRange,
+				ParagraphStyle: &docs.ParagraphStyle{NamedStyleType: "TITLE"},
+				Fields:         "namedStyleType",
+			},
+		},
+	}
+	for _, heading := range headings {
+		requests = append(requests, &docs.Request{
+			UpdateParagraphStyle: &docs.UpdateParagraphStyleRequest{
+				Range:          heading,
+				ParagraphStyle: &docs.ParagraphStyle{NamedStyleType: "HEADING_2"},
+				Fields:         "namedStyleType",
+			},
+		})
+	}
+
+	return requests
+}
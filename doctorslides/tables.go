@@ -0,0 +1,145 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/slides/v1"
+	"strings"
+)
+
+// Tables bigger than this stay as bullets instead of becoming a real table on
+// the slide, since anything larger is unreadable at slide size anyway
+const (
+	MAX_SLIDE_TABLE_ROWS    = 6
+	MAX_SLIDE_TABLE_COLUMNS = 4
+)
+
+// tableCells pulls the text out of every cell in a doc table. Merged cells
+// come through from the API as empty cells, and rows can be ragged, so the
+// rows get evened out.
+func tableCells(table *docs.Table) [][]string {
+	rows := make([][]string, 0)
+	for _, tableRow := range table.TableRows {
+		if tableRow == nil {
+			continue
+		}
+		row := make([]string, 0)
+		for _, cell := range tableRow.TableCells {
+			row = append(row, tableCellText(cell))
+		}
+		rows = append(rows, row)
+	}
+
+	return normalizeTable(rows)
+}
+
+func tableCellText(cell *docs.TableCell) string {
+	if cell == nil {
+		return ""
+	}
+	var text strings.Builder
+	for _, element := range cell.Content {
+		if element.Paragraph != nil {
+			text.WriteString(paragraphText(element.Paragraph))
+		}
+	}
+
+	return strings.Join(strings.Fields(text.String()), " ")
+}
+
+// tableText writes a table out for GPT the same way it's asked to give tables
+// back, one table line per row with the cells split by pipes
+func tableText(rows [][]string) string {
+	if len(rows) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n")
+	for _, row := range rows {
+		fmt.Fprintf(&b, "%s%s\n", OUTLINE_FORMAT.Table, strings.Join(row, " | "))
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// parseTableRow reads a row back out of a table line
+func parseTableRow(line string) []string {
+	cells := strings.Split(line, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+
+	return cells
+}
+
+// normalizeTable pads out short rows so every row is as wide as the widest one
+func normalizeTable(rows [][]string) [][]string {
+	width := 0
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	for i := range rows {
+		for len(rows[i]) < width {
+			rows[i] = append(rows[i], "")
+		}
+	}
+
+	return rows
+}
+
+// fitsOnSlide is whether a table is small enough to draw as a real table
+func fitsOnSlide(rows [][]string) bool {
+	return len(rows) > 0 &&
+		len(rows) <= MAX_SLIDE_TABLE_ROWS &&
+		len(rows[0]) > 0 &&
+		len(rows[0]) <= MAX_SLIDE_TABLE_COLUMNS
+}
+
+// tableAsBullets turns a table into a bullet per row for when it can't be
+// drawn as a real one
+func tableAsBullets(slide SimpleSlide) SimpleSlide {
+	for _, row := range slide.Table {
+		slide.Bullets = append(slide.Bullets, Bullet{Text: strings.Join(row, " | ")})
+	}
+	slide.Table = nil
+
+	return slide
+}
+
+// buildTableRequests makes a table in the body area of the slide and fills
+// in its cells. Slides won't insert empty text, so empty cells are skipped.
+func buildTableRequests(slide *slides.Page, rows [][]string) []*slides.Request {
+	tableId := slide.ObjectId + "_table"
+	requests := []*slides.Request{
+		{
+			CreateTable: &slides.CreateTableRequest{
+				ObjectId:          tableId,
+				ElementProperties: elementProperties(slide.ObjectId, bodyBox()),
+				Rows:              int64(len(rows)),
+				Columns:           int64(len(rows[0])),
+			},
+		},
+	}
+	for r, row := range rows {
+		for c, cell := range row {
+			if cell == "" {
+				continue
+			}
+			requests = append(requests, &slides.Request{
+				InsertText: &slides.InsertTextRequest{
+					ObjectId: tableId,
+					CellLocation: &slides.TableCellLocation{
+						RowIndex:    int64(r),
+						ColumnIndex: int64(c),
+					},
+					Text: cell,
+				},
+			})
+		}
+	}
+
+	return requests
+}
@@ -0,0 +1,227 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/slides/v1"
+	"sort"
+	"strings"
+)
+
+// Theme is a palette for the deck's master along with the fonts for titles
+// and body text. Colors are keyed by the theme color type they replace, like
+// ACCENT1, and an empty font leaves the deck's own.
+type Theme struct {
+	Colors    map[string]*slides.RgbColor
+	TitleFont string
+	BodyFont  string
+}
+
+func rgb(hex uint32) *slides.RgbColor {
+	return &slides.RgbColor{
+		Red:   float64(hex>>16&0xff) / 255,
+		Green: float64(hex>>8&0xff) / 255,
+		Blue:  float64(hex&0xff) / 255,
+	}
+}
+
+// THEMES are the ones --theme knows by name. Slides only lets the first
+// twelve theme colors be changed, and wants all of them at once.
+var THEMES = map[string]Theme{
+	"light": {
+		Colors: map[string]*slides.RgbColor{
+			"DARK1": rgb(0x000000), "LIGHT1": rgb(0xffffff), "DARK2": rgb(0x595959), "LIGHT2": rgb(0xeeeeee),
+			"ACCENT1": rgb(0x4285f4), "ACCENT2": rgb(0x212121), "ACCENT3": rgb(0x78909c), "ACCENT4": rgb(0xffab40),
+			"ACCENT5": rgb(0x0097a7), "ACCENT6": rgb(0xeeff41), "HYPERLINK": rgb(0x0097a7), "FOLLOWED_HYPERLINK": rgb(0x0097a7),
+		},
+		TitleFont: "Arial",
+		BodyFont:  "Arial",
+	},
+	"dark": {
+		Colors: map[string]*slides.RgbColor{
+			"DARK1": rgb(0xffffff), "LIGHT1": rgb(0x212121), "DARK2": rgb(0xeeeeee), "LIGHT2": rgb(0x424242),
+			"ACCENT1": rgb(0x82b1ff), "ACCENT2": rgb(0xffffff), "ACCENT3": rgb(0xb0bec5), "ACCENT4": rgb(0xffd180),
+			"ACCENT5": rgb(0x84ffff), "ACCENT6": rgb(0xf4ff81), "HYPERLINK": rgb(0x84ffff), "FOLLOWED_HYPERLINK": rgb(0x84ffff),
+		},
+		TitleFont: "Roboto",
+		BodyFont:  "Roboto",
+	},
+	"forest": {
+		Colors: map[string]*slides.RgbColor{
+			"DARK1": rgb(0x1b2e1b), "LIGHT1": rgb(0xf7f5ee), "DARK2": rgb(0x3e5c3e), "LIGHT2": rgb(0xe3e8d8),
+			"ACCENT1": rgb(0x4c7a34), "ACCENT2": rgb(0x8b5e34), "ACCENT3": rgb(0xa3b18a), "ACCENT4": rgb(0xd9a441),
+			"ACCENT5": rgb(0x588157), "ACCENT6": rgb(0xc97c5d), "HYPERLINK": rgb(0x4c7a34), "FOLLOWED_HYPERLINK": rgb(0x3e5c3e),
+		},
+		TitleFont: "Georgia",
+		BodyFont:  "Lato",
+	},
+}
+
+func themeNames() string {
+	names := make([]string, 0)
+	for name := range THEMES {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return strings.Join(names, ", ")
+}
+
+// themeFromPresentation samples another deck's look: the color scheme off its
+// first master, and the fonts its master title and body placeholders use
+func themeFromPresentation(presentationId string) (Theme, error) {
+	presentation, err := getSlidesService().Presentations.Get(presentationId).Context(CTX).Do()
+	if err != nil {
+		return Theme{}, fmt.Errorf("could not read presentation %s: %w", presentationId, err)
+	}
+	if len(presentation.Masters) == 0 || presentation.Masters[0].PageProperties == nil ||
+		presentation.Masters[0].PageProperties.ColorScheme == nil {
+		return Theme{}, fmt.Errorf("presentation %s doesn't have a color scheme to copy", presentationId)
+	}
+	master := presentation.Masters[0]
+	theme := Theme{Colors: make(map[string]*slides.RgbColor)}
+	for _, color := range master.PageProperties.ColorScheme.Colors {
+		if color.Color != nil {
+			theme.Colors[color.Type] = color.Color
+		}
+	}
+	theme.TitleFont = placeholderFont(master, "TITLE", "CENTERED_TITLE")
+	theme.BodyFont = placeholderFont(master, "BODY", "SUBTITLE")
+
+	return theme, nil
+}
+
+// placeholderFont is the first font set on any of the text in a placeholder
+func placeholderFont(page *slides.Page, placeholderTypes ...string) string {
+	placeholder := findPlaceholder(page, placeholderTypes...)
+	if placeholder == nil || placeholder.Shape.Text == nil {
+		return ""
+	}
+	for _, element := range placeholder.Shape.Text.TextElements {
+		if element.TextRun != nil && element.TextRun.Style != nil && element.TextRun.Style.FontFamily != "" {
+			return element.TextRun.Style.FontFamily
+		}
+	}
+
+	return ""
+}
+
+// chosenTheme works out the theme for the new deck. --theme-from wins when
+// it works, and --theme is what's left when it doesn't.
+func chosenTheme() (Theme, bool) {
+	if options.ThemeFrom != "" {
+		theme, err := themeFromPresentation(options.ThemeFrom)
+		if err == nil {
+			return theme, true
+		}
+		if options.Theme == "" {
+			logf("Could not take the theme from the other deck, keeping the default look: %s\n", err)
+			return Theme{}, false
+		}
+		logf("Could not take the theme from the other deck, using the %s theme instead: %s\n", options.Theme, err)
+	}
+	theme, ok := THEMES[options.Theme]
+
+	return theme, ok
+}
+
+// buildColorSchemeRequests puts the palette on every master in the deck,
+// which everything else in it inherits from
+func buildColorSchemeRequests(presentation *slides.Presentation, theme Theme) []*slides.Request {
+	if len(theme.Colors) == 0 {
+		return nil
+	}
+	types := make([]string, 0)
+	for colorType := range theme.Colors {
+		types = append(types, colorType)
+	}
+	sort.Strings(types)
+	colors := make([]*slides.ThemeColorPair, 0)
+	for _, colorType := range types {
+		colors = append(colors, &slides.ThemeColorPair{Type: colorType, Color: theme.Colors[colorType]})
+	}
+	requests := make([]*slides.Request, 0)
+	for _, master := range presentation.Masters {
+		requests = append(requests, &slides.Request{
+			UpdatePageProperties: &slides.UpdatePagePropertiesRequest{
+				ObjectId: master.ObjectId,
+				PageProperties: &slides.PageProperties{
+					ColorScheme: &slides.ColorScheme{Colors: colors},
+				},
+				Fields: "colorScheme.colors",
+			},
+		})
+	}
+
+	return requests
+}
+
+// buildThemeFontRequests sets the fonts on the titles and bodies once the
+// text is in, since Slides won't style text that isn't there yet. Code boxes
+// keep their monospace font.
+func buildThemeFontRequests(presentation *slides.Presentation, theme Theme) []*slides.Request {
+	requests := make([]*slides.Request, 0)
+	for _, slide := range presentation.Slides {
+		for _, element := range slide.PageElements {
+			if element.Shape == nil || element.Shape.Text == nil || len(element.Shape.Text.TextElements) == 0 {
+				continue
+			}
+			font := ""
+			switch {
+			case isTitleElement(slide, element):
+				font = theme.TitleFont
+			case isBodyElement(slide, element):
+				font = theme.BodyFont
+			}
+			if font == "" {
+				continue
+			}
+			requests = append(requests, &slides.Request{
+				UpdateTextStyle: &slides.UpdateTextStyleRequest{
+					ObjectId: element.ObjectId,
+					TextRange: &slides.Range{
+						Type: "ALL",
+					},
+					Style: &slides.TextStyle{
+						FontFamily: font,
+					},
+					Fields: "fontFamily",
+				},
+			})
+		}
+	}
+
+	return requests
+}
+
+// applyThemeFonts reads the finished deck back to find the text to set the
+// fonts on
+func applyThemeFonts(writer SlideWriter, presentationId string, theme Theme) {
+	presentation, err := writer.Get(presentationId, SLIDE_TEXT_FIELDS)
+	if err != nil {
+		panic(err)
+	}
+	requests := buildThemeFontRequests(presentation, theme)
+	if len(requests) == 0 {
+		return
+	}
+	err = batchUpdate(writer, presentationId, &slides.BatchUpdatePresentationRequest{Requests: requests})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func isTitleElement(slide *slides.Page, element *slides.PageElement) bool {
+	if placeholder := element.Shape.Placeholder; placeholder != nil {
+		return placeholder.Type == "TITLE" || placeholder.Type == "CENTERED_TITLE"
+	}
+
+	return element.ObjectId == slide.ObjectId+"_title"
+}
+
+func isBodyElement(slide *slides.Page, element *slides.PageElement) bool {
+	if placeholder := element.Shape.Placeholder; placeholder != nil {
+		return placeholder.Type == "BODY" || placeholder.Type == "SUBTITLE"
+	}
+
+	return element.ObjectId == slide.ObjectId+"_body"
+}
@@ -0,0 +1,112 @@
+package doctorslides
+
+import (
+	"errors"
+	"fmt"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/slides/v1"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var THUMBNAIL_SIZES = []string{"SMALL", "MEDIUM", "LARGE"}
+
+// THUMBNAIL_FIELDS is the slide IDs and enough of each title box to name the
+// thumbnail files after it
+const THUMBNAIL_FIELDS = "slides(objectId,pageElements(objectId,shape(placeholder,text(textElements(textRun(content))))))"
+
+// How many times a throttled call gets tried again, and how long to wait
+// before the first of them. The wait doubles each time.
+const (
+	BACKOFF_RETRIES = 5
+	BACKOFF_START   = time.Second
+)
+
+// withBackoff runs call until it works, waiting longer each time Google
+// says to slow down or has a hiccup. Anything else fails straight away.
+func withBackoff(call func() error) error {
+	wait := BACKOFF_START
+	for attempt := 0; ; attempt++ {
+		err := call()
+		var apiErr *googleapi.Error
+		if err == nil || attempt == BACKOFF_RETRIES || !errors.As(err, &apiErr) ||
+			(apiErr.Code != http.StatusTooManyRequests && apiErr.Code < 500) {
+			return err
+		}
+		if DEBUG {
+			logf("Google said %d, trying again in %s\n", apiErr.Code, wait)
+		}
+		select {
+		case <-CTX.Done():
+			return CTX.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// saveThumbnails renders every slide in the deck to a PNG in dir, named for
+// its place in the deck and its title so they sort in order
+func saveThumbnails(presentationId string, dir string, size string) {
+	logf("Saving slide thumbnails to: %s\n", dir)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		logln("Could not make the thumbnail directory")
+		panic(err)
+	}
+	slidesService := getSlidesService()
+	presentation, err := slidesService.Presentations.Get(presentationId).Fields(THUMBNAIL_FIELDS).Context(CTX).Do()
+	if err != nil {
+		logln("Could not read the presentation")
+		panic(err)
+	}
+	for i, slide := range presentation.Slides {
+		var thumbnail *slides.Thumbnail
+		err := withBackoff(func() error {
+			var err error
+			thumbnail, err = slidesService.Presentations.Pages.GetThumbnail(presentationId, slide.ObjectId).
+				ThumbnailPropertiesMimeType("PNG").
+				ThumbnailPropertiesThumbnailSize(size).
+				Context(CTX).
+				Do()
+			return err
+		})
+		if err != nil {
+			logf("Could not get a thumbnail of slide %d: %s\n", i+1, err)
+			continue
+		}
+		title, _ := findTextBox(slide, "title", "TITLE", "CENTERED_TITLE")
+		name := fmt.Sprintf("%02d-%s.png", i+1, safeFileName(shapeText(title)))
+		err = downloadThumbnail(thumbnail.ContentUrl, filepath.Join(dir, name))
+		if err != nil {
+			logf("Could not save the thumbnail of slide %d: %s\n", i+1, err)
+		}
+	}
+}
+
+// downloadThumbnail fetches the rendered PNG. The URL is only good for a
+// little while but doesn't need signing in.
+func downloadThumbnail(url string, path string) error {
+	resp, err := httpClient().Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the thumbnail download said %s", resp.Status)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, resp.Body)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
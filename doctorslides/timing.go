@@ -0,0 +1,69 @@
+package doctorslides
+
+import (
+	"strings"
+)
+
+// estimateSlideSeconds guesses how long a slide needs to stay up for someone
+// to read it. The second value is false when there's nothing to go off of.
+func estimateSlideSeconds(slide SimpleSlide, wordsPerMinute int) (int, bool) {
+	if wordsPerMinute <= 0 {
+		return 0, false
+	}
+	words := len(strings.Fields(slide.Title))
+	for _, bullet := range bulletTexts(slide.Bullets) {
+		words += len(strings.Fields(bullet))
+	}
+	if words == 0 {
+		return 0, false
+	}
+	// Round up so short slides don't flash by in zero seconds
+	seconds := (words*60 + wordsPerMinute - 1) / wordsPerMinute
+
+	return seconds, true
+}
+
+// printAutoAdvancePlan reports the timing for each content slide. The Slides
+// API doesn't expose per-slide auto-advance timing (SlideProperties has no
+// such field and there's no request for it), so the best we can do is hand
+// the numbers over for whoever sets up the kiosk.
+func printAutoAdvancePlan(outline GPTOutline, wordsPerMinute int) {
+	logf("Auto-advance timing at %d words per minute:\n", wordsPerMinute)
+	for i, slide := range outline.Slides {
+		seconds, ok := estimateSlideSeconds(slide, wordsPerMinute)
+		if !ok {
+			logf("  %d. %s: skipped, nothing to time\n", i+1, slide.Title)
+			continue
+		}
+		logf("  %d. %s: %ds\n", i+1, slide.Title, seconds)
+	}
+	logln("Google Slides can't set these through the API, so you'll have to apply them by hand.")
+}
+
+// TRANSITIONS are the transitions Google Slides offers, by the names used in
+// its transition menu
+var TRANSITIONS = map[string]string{
+	"NONE":             "None",
+	"DISSOLVE":         "Dissolve",
+	"FADE":             "Fade",
+	"SLIDE_FROM_RIGHT": "Slide from right",
+	"SLIDE_FROM_LEFT":  "Slide from left",
+	"FLIP":             "Flip",
+	"CUBE":             "Cube",
+	"GALLERY":          "Gallery",
+}
+
+func isValidTransition(name string) bool {
+	_, ok := TRANSITIONS[name]
+	return ok
+}
+
+// printTransitionNote is the same story as auto-advance: the Slides API has
+// no request for setting transitions, so all we can do is say what to pick.
+// NONE is what new decks already have, so that one needs nothing.
+func printTransitionNote(name string) {
+	if name == "NONE" {
+		return
+	}
+	logf("Google Slides can't set transitions through the API. To finish up, open the deck, go to Slide > Transition, pick \"%s\", and hit \"Apply to all slides\".\n", TRANSITIONS[name])
+}
@@ -0,0 +1,71 @@
+package doctorslides
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// UNNAMED_TITLE is what the parser gives slides GPT didn't title. It's only a
+// marker and gets replaced before anything reaches a real slide.
+const UNNAMED_TITLE = "[UNNAMED]"
+
+// How long a title made from a slide's first bullet can be
+const DERIVED_TITLE_CHARS = 40
+
+// DEFAULT_TIME_FORMAT is how --time-format stamps a deck that has no title
+const DEFAULT_TIME_FORMAT = "2006-01-02 15:04"
+
+// deckTimestamp is the time in the local timezone, the way --time-format
+// lays it out
+func deckTimestamp(now time.Time) string {
+	return now.Local().Format(options.TimeFormat)
+}
+
+// defaultDeckTitle is for decks with nothing to name them after, like an
+// outline file that never set a title
+func defaultDeckTitle(now time.Time) string {
+	return "Slides — " + deckTimestamp(now)
+}
+
+// titleUntitledSlides comes up with titles for the slides GPT left without
+// one. With --ai-titles GPT gets asked for one, otherwise (or if that fails)
+// the first bullet gets cut down into one, and a slide without any bullets
+// gets --untitled-label. A deck without a title gets one with the time in it.
+func titleUntitledSlides(outline GPTOutline) GPTOutline {
+	if strings.TrimSpace(outline.Title) == "" {
+		outline.Title = defaultDeckTitle(time.Now())
+	}
+	for i := range outline.Slides {
+		slide := &outline.Slides[i]
+		if slide.Title != UNNAMED_TITLE && strings.TrimSpace(slide.Title) != "" {
+			continue
+		}
+		slide.Title = options.UntitledLabel
+		if len(slide.Bullets) == 0 {
+			continue
+		}
+		if options.AITitles {
+			title, err := askForTitle(slide.Bullets)
+			if err == nil && title != "" {
+				slide.Title = title
+				continue
+			}
+			logf("Could not get a title from GPT for slide %d, using its first bullet: %s\n", i+1, err)
+		}
+		slide.Title, _ = truncateBullet(slide.Bullets[0].Text, DERIVED_TITLE_CHARS)
+	}
+
+	return outline
+}
+
+func askForTitle(bullets []Bullet) (string, error) {
+	template := `
+	Give a short title, no more than six words, for a presentation slide with
+	these bullet points. Answer with only the title.
+
+	%s`
+	title, err := askGPT(fmt.Sprintf(template, strings.Join(bulletTexts(bullets), "\n")))
+
+	return strings.Trim(title, "\"'"), err
+}
@@ -0,0 +1,89 @@
+package doctorslides
+
+import (
+	"context"
+	"golang.org/x/oauth2"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TRANSPORT is what every request we make goes through, OpenAI, Google, and
+// the image sites alike. Leave it nil for Go's default, or swap in your own
+// to watch or change the traffic.
+var TRANSPORT http.RoundTripper
+
+// SECRET_QUERY_PARAMS are left out of traced URLs since some APIs take their
+// keys in the query string
+var SECRET_QUERY_PARAMS = []string{"key", "api_key", "client_id", "access_token", "code"}
+
+func httpClient() *http.Client {
+	return &http.Client{Transport: TRANSPORT}
+}
+
+// googleContext hands our client to the oauth2 package, which otherwise makes
+// its own for both the token exchange and the API calls
+func googleContext() context.Context {
+	return context.WithValue(context.Background(), oauth2.HTTPClient, httpClient())
+}
+
+// headerTransport adds a header to every request that goes through it
+type headerTransport struct {
+	next  http.RoundTripper
+	name  string
+	value string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	// RoundTrippers aren't supposed to change the request they're given
+	req = req.Clone(req.Context())
+	req.Header.Set(t.name, t.value)
+
+	return next.RoundTrip(req)
+}
+
+// tracingTransport logs one line per request with how it went. Headers are
+// never printed, so the Authorization header and bearer tokens stay out of
+// the logs.
+type tracingTransport struct {
+	next http.RoundTripper
+}
+
+func newTracingTransport(next http.RoundTripper) *tracingTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &tracingTransport{next: next}
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		logf("[http] %s %s failed after %s: %s\n", req.Method, redactURL(req.URL), elapsed, err)
+		return resp, err
+	}
+	logf("[http] %s %s %d %s\n", req.Method, redactURL(req.URL), resp.StatusCode, elapsed)
+
+	return resp, nil
+}
+
+func redactURL(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	for _, param := range SECRET_QUERY_PARAMS {
+		if query.Has(param) {
+			query.Set(param, "REDACTED")
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	redacted.User = nil
+
+	return redacted.String()
+}
@@ -0,0 +1,119 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/slides/v1"
+	"strconv"
+	"strings"
+)
+
+// parseSlideRange reads ranges like "3-5" or just "4". Slide numbers count
+// content slides from 1, so the title slide isn't part of the numbering.
+func parseSlideRange(value string) (int, int, error) {
+	startText, endText, isRange := strings.Cut(value, "-")
+	if !isRange {
+		endText = startText
+	}
+	start, err := strconv.Atoi(strings.TrimSpace(startText))
+	if err != nil {
+		return 0, 0, fmt.Errorf("\"%s\" isn't a slide range like 3-5", value)
+	}
+	end, err := strconv.Atoi(strings.TrimSpace(endText))
+	if err != nil {
+		return 0, 0, fmt.Errorf("\"%s\" isn't a slide range like 3-5", value)
+	}
+	if start < 1 || end < start {
+		return 0, 0, fmt.Errorf("\"%s\" needs to go from a lower slide number to a higher one, starting at 1", value)
+	}
+
+	return start, end, nil
+}
+
+// updateSlideRange swaps the text in a few content slides of an existing deck
+// for freshly generated text, leaving everything else in the deck alone.
+// Content slide n in the outline lines up with slide n in the deck since the
+// title slide sits at index 0, or with slide n-1 for decks made with
+// --no-title-slide. Decks made with --agenda need it passed again here so
+// the agenda gets skipped over too. The speaker notes stay as they are unless
+// --existing-notes says to merge or replace them.
+func updateSlideRange(presentationId string, start int, end int, outline GPTOutline) (string, string) {
+	validateOutline(outline)
+	checkSlideFit(outline)
+	logln("Updating your slide show")
+	slidesService := getSlidesService()
+	presentation, err := slidesService.Presentations.Get(presentationId).Context(CTX).Do()
+	if err != nil {
+		logln("Could not read the presentation")
+		panic(err)
+	}
+	// The deck has a title slide (and maybe an agenda) up front and a
+	// closing slide at the end
+	firstContentSlide := 1
+	if options.NoTitleSlide {
+		firstContentSlide = 0
+	}
+	if options.Agenda {
+		firstContentSlide++
+	}
+	contentSlides := len(presentation.Slides) - firstContentSlide - 1
+	if end > contentSlides {
+		fatalf("The deck only has %d content slides, so I can't update %d-%d", contentSlides, start, end)
+	}
+	if end > len(outline.Slides) {
+		fatalf("GPT only came up with %d slides this time, so I can't update %d-%d", len(outline.Slides), start, end)
+	}
+
+	updates := slides.BatchUpdatePresentationRequest{}
+	updates.Requests = make([]*slides.Request, 0)
+	for i := start; i <= end; i++ {
+		slide := presentation.Slides[firstContentSlide+i-1]
+		if title, _ := findTextBox(slide, "title", "TITLE", "CENTERED_TITLE"); title != nil {
+			updates.Requests = append(updates.Requests, buildClearTextRequests(title)...)
+		}
+		if body, _ := findTextBox(slide, "body", "BODY", "SUBTITLE"); body != nil {
+			updates.Requests = append(updates.Requests, buildClearTextRequests(body)...)
+		}
+		// An existing slide has nowhere to put a new table, so any tables
+		// go in as bullets
+		slideOutline := tableAsBullets(outline.Slides[i-1])
+		updates.Requests = append(updates.Requests, buildContentTextRequests(slide, slideOutline)...)
+		updates.Requests = append(updates.Requests, buildRemoveCodeRequests(slide)...)
+		updates.Requests = append(updates.Requests, buildExistingNotesRequests(slide, slideOutline.Notes, options.ExistingNotes)...)
+		if fill := slideBackgroundFill(slideOutline); fill != nil {
+			updates.Requests = append(updates.Requests, buildBackgroundRequest(slide.ObjectId, fill))
+		}
+		if slideOutline.Code != "" {
+			updates.Requests = append(updates.Requests, buildCodeRequests(slide, slideOutline.Code, len(slideOutline.Bullets) > 0)...)
+		}
+	}
+	if len(updates.Requests) > 0 {
+		err = batchUpdate(liveSlideWriter{slidesService}, presentationId, &updates)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	url := presentationURL(presentationId)
+	logf("Updated slides %d-%d of: %s\n", start, end, url)
+
+	return presentationId, url
+}
+
+// buildClearTextRequests empties a placeholder. Slides won't let you delete
+// text from a shape that doesn't have any, so empty ones are left alone.
+func buildClearTextRequests(element *slides.PageElement) []*slides.Request {
+	if element.Shape == nil || element.Shape.Text == nil || len(element.Shape.Text.TextElements) == 0 {
+		return nil
+	}
+
+	return []*slides.Request{
+		{
+			DeleteText: &slides.DeleteTextRequest{
+				ObjectId: element.ObjectId,
+				TextRange: &slides.Range{
+					Type: "ALL",
+				},
+			},
+		},
+	}
+}
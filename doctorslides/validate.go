@@ -0,0 +1,109 @@
+package doctorslides
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// validateOutline looks the outline over before any of it goes to Slides and
+// lists everything wrong with it. That's only a warning unless --strict says
+// to stop there instead. The image URLs are only checked for looking right
+// here, --check-images is the one that actually goes and gets them.
+func validateOutline(outline GPTOutline) {
+	if !options.ValidateOutline && !options.Strict {
+		return
+	}
+	problems := outlineProblems(outline)
+	if len(problems) == 0 {
+		if DEBUG {
+			logln("The outline looks fine")
+		}
+		return
+	}
+
+	logf("The outline has %d problems:\n", len(problems))
+	for _, problem := range problems {
+		logf("  %s\n", problem)
+	}
+	if options.Strict {
+		fatalf("Not making the deck because of --strict.")
+	}
+}
+
+func outlineProblems(outline GPTOutline) []string {
+	problems := make([]string, 0)
+	if strings.TrimSpace(outline.Title) == "" {
+		problems = append(problems, "The deck doesn't have a title")
+	}
+	if min, max, ok := slideCountBounds(); ok {
+		if len(outline.Slides) < min || len(outline.Slides) > max {
+			problems = append(problems, fmt.Sprintf("There are %d slides, which isn't between %d and %d", len(outline.Slides), min, max))
+		}
+	}
+	maxBullets := options.FitMaxBullets
+	if options.MaxBullets > 0 {
+		maxBullets = options.MaxBullets
+	}
+	for i, slide := range outline.Slides {
+		name := fmt.Sprintf("Slide %d (\"%s\")", i+1, slide.Title)
+		if strings.TrimSpace(slide.Title) == "" {
+			name = fmt.Sprintf("Slide %d", i+1)
+			problems = append(problems, name+" doesn't have a title")
+		}
+		hasContent := len(slide.Bullets) > 0 || len(slide.Table) > 0 || slide.Code != "" || slide.Image != "" || slide.Video != ""
+		if !hasContent {
+			problems = append(problems, name+" doesn't have anything on it")
+		}
+		if len(slide.Bullets) > maxBullets {
+			problems = append(problems, fmt.Sprintf("%s has %d bullets, more than %d", name, len(slide.Bullets), maxBullets))
+		}
+		if slide.Image != "" {
+			if err := checkImageURLShape(slide.Image); err != nil {
+				problems = append(problems, fmt.Sprintf("%s has an image URL that %s: %s", name, err, slide.Image))
+			}
+		}
+		if slide.Background != "" {
+			if _, err := parseColor(slide.Background); err != nil {
+				problems = append(problems, fmt.Sprintf("%s has a background that %s", name, err))
+			}
+		}
+		if slide.Video != "" {
+			if _, err := youTubeId(slide.Video); err != nil {
+				problems = append(problems, fmt.Sprintf("%s has a video that won't play: %s", name, err))
+			}
+		}
+	}
+
+	return problems
+}
+
+// slideCountBounds is the range of slides the outline should have. Only
+// GPT gets told how many to make, so outlines from anywhere else only get
+// held to the counts that were asked for outright.
+func slideCountBounds() (int, int, bool) {
+	if options.ExactSlides > 0 {
+		return options.ExactSlides, options.ExactSlides, true
+	}
+	fromGPT := options.FromOutline == "" && !options.NoAI && !options.readsMarkdown
+	if !fromGPT && !options.explicit["min-slides"] && !options.explicit["max-slides"] {
+		return 0, 0, false
+	}
+
+	return options.MinSlides, options.MaxSlides, true
+}
+
+func checkImageURLShape(imageURL string) error {
+	parsed, err := url.Parse(imageURL)
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("isn't a full URL")
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("isn't http or https")
+	}
+	if !isAllowedImageHost(imageURL) {
+		return errImageNotAllowed
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/slides/v1"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// YouTube video IDs are always 11 of these
+var YOUTUBE_ID = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+// youTubeId pulls the video ID out of the usual kinds of YouTube link, or
+// takes a bare ID as is
+func youTubeId(video string) (string, error) {
+	video = strings.TrimSpace(video)
+	if YOUTUBE_ID.MatchString(video) {
+		return video, nil
+	}
+	parsed, err := url.Parse(video)
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("\"%s\" isn't a YouTube link or video ID", video)
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	id := ""
+	switch host {
+	case "youtu.be":
+		id = strings.Trim(parsed.Path, "/")
+	case "youtube.com", "youtube-nocookie.com":
+		if parsed.Path == "/watch" {
+			id = parsed.Query().Get("v")
+		} else {
+			for _, prefix := range []string{"/embed/", "/shorts/", "/live/", "/v/"} {
+				if strings.HasPrefix(parsed.Path, prefix) {
+					id = strings.Trim(strings.TrimPrefix(parsed.Path, prefix), "/")
+				}
+			}
+		}
+	default:
+		return "", fmt.Errorf("\"%s\" isn't a YouTube link, and YouTube is the only place Slides can play videos from", video)
+	}
+	if !YOUTUBE_ID.MatchString(id) {
+		return "", fmt.Errorf("\"%s\" doesn't have a YouTube video ID in it", video)
+	}
+
+	return id, nil
+}
+
+// buildVideoRequests puts the video where the image would go, sized to 16:9
+// the same way a photo of that shape would be
+func buildVideoRequests(slideId string, index int, videoId string) []*slides.Request {
+	placement := placeImage(SlideImage{Width: 16, Height: 9}, "contain")
+
+	return []*slides.Request{
+		{
+			CreateVideo: &slides.CreateVideoRequest{
+				ObjectId:          fmt.Sprintf("%s_video_%d", slideId, index),
+				Source:            "YOUTUBE",
+				Id:                videoId,
+				ElementProperties: elementProperties(slideId, placement),
+			},
+		},
+	}
+}
@@ -0,0 +1,149 @@
+package doctorslides
+
+import (
+	"fmt"
+	"google.golang.org/api/slides/v1"
+	"time"
+)
+
+// WATCH_TIME_FORMAT stamps each line --watch prints
+const WATCH_TIME_FORMAT = "15:04:05"
+
+// rebuildSlideWriter builds the deck into one that's already there instead
+// of making a new one, so --watch keeps the same link. Create clears out the
+// old slides and leaves a fresh TITLE slide, the same as a new deck starts.
+type rebuildSlideWriter struct {
+	liveSlideWriter
+	presentationId string
+}
+
+func (w rebuildSlideWriter) Create(presentation *slides.Presentation) (*slides.Presentation, error) {
+	existing, err := w.Get(w.presentationId, "slides(objectId)")
+	if err != nil {
+		return nil, err
+	}
+	// The new slide goes in first, since a deck can't be left with none
+	requests := []*slides.Request{
+		{
+			CreateSlide: &slides.CreateSlideRequest{
+				SlideLayoutReference: &slides.LayoutReference{
+					PredefinedLayout: "TITLE",
+				},
+			},
+		},
+	}
+	for _, slide := range existing.Slides {
+		requests = append(requests, &slides.Request{
+			DeleteObject: &slides.DeleteObjectRequest{
+				ObjectId: slide.ObjectId,
+			},
+		})
+	}
+	_, err = w.BatchUpdate(w.presentationId, &slides.BatchUpdatePresentationRequest{Requests: requests})
+	if err != nil {
+		return nil, err
+	}
+
+	return w.Get(w.presentationId, SLIDE_LAYOUT_FIELDS)
+}
+
+func watchLogf(format string, a ...any) {
+	logf("[%s] %s\n", time.Now().Format(WATCH_TIME_FORMAT), fmt.Sprintf(format, a...))
+}
+
+// documentRevision is only the doc's revision ID, which changes whenever the
+// doc does, so checking it is cheap
+func documentRevision(documentId string) (string, error) {
+	doc, err := getDocsService().Documents.Get(documentId).Fields("revisionId").Context(CTX).Do()
+	if err != nil {
+		return "", err
+	}
+
+	return doc.RevisionId, nil
+}
+
+// watchDocument regenerates the deck every time the doc changes until it
+// gets a Ctrl+C. The first run makes the deck unless --presentation-id
+// gives one, and every run after that rebuilds it in place. Nothing that
+// goes wrong along the way stops the watching, it just gets tried again on
+// the next check.
+//
+// Ctrl+C lets a run that's going finish before the watching stops, so the
+// deck isn't left half rebuilt. A second one cancels the run too.
+func watchDocument(documentId string) {
+	stopping := make(chan struct{})
+	interrupts := 0
+	restore := onInterrupt(func() {
+		interrupts++
+		if interrupts == 1 {
+			logln("Stopping once this run is done, press Ctrl+C again to stop now")
+			close(stopping)
+			return
+		}
+		cancelCTX()
+	})
+	defer restore()
+	logf("Watching the doc for changes every %s, press Ctrl+C to stop\n", options.WatchInterval)
+	ticker := time.NewTicker(options.WatchInterval)
+	defer ticker.Stop()
+	presentationId := options.PresentationId
+	lastRevision := ""
+	for {
+		revision, err := documentRevision(documentId)
+		if err != nil {
+			watchLogf("Could not check the doc for changes, trying again in %s: %s", options.WatchInterval, err)
+		} else if revision != lastRevision {
+			id, url, err := regenerateDeck(documentId, presentationId)
+			if err != nil {
+				watchLogf("Could not regenerate the deck, trying again in %s: %s", options.WatchInterval, err)
+			} else {
+				lastRevision = revision
+				presentationId = id
+				watchLogf("Regenerated the deck: %s", url)
+			}
+		}
+		// The ticker has usually gone off already when a run takes longer
+		// than the interval, so stopping gets checked first
+		select {
+		case <-stopping:
+			logln("Stopped watching")
+			return
+		default:
+		}
+		select {
+		case <-stopping:
+			logln("Stopped watching")
+			return
+		case <-CTX.Done():
+			logln("Stopped watching")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// regenerateDeck runs the whole pipeline once. fatalf panics instead of
+// quitting while it runs, so one bad run comes back as an error.
+func regenerateDeck(documentId string, presentationId string) (id string, url string, err error) {
+	saved := options.collectFailures
+	options.collectFailures = true
+	defer func() {
+		options.collectFailures = saved
+		if r := recover(); r != nil {
+			err = panicError(r)
+		}
+	}()
+	outline := buildOutline([]string{documentId})
+	switch {
+	case options.SlideRange != "":
+		id, url = updateSlideRange(presentationId, options.slideRangeStart, options.slideRangeEnd, outline)
+	case presentationId != "":
+		validateOutline(outline)
+		checkSlideFit(outline)
+		id, url = writeSlides(rebuildSlideWriter{liveSlideWriter{getSlidesService()}, presentationId}, outline)
+	default:
+		id, url = writeToSlides(outline)
+	}
+
+	return id, url, nil
+}